@@ -0,0 +1,65 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"gitlab.cloudferro.com/k8s/api/kubernetesversion/v1"
+	"gitlab.cloudferro.com/k8s/api/kubernetesversionservice/v1"
+	"gitlab.cloudferro.com/k8s/api/machinespec/v1"
+	"gitlab.cloudferro.com/k8s/api/machinespecservice/v1"
+)
+
+// ResolveFlavor returns the machine spec with the given name.
+func (c *Client) ResolveFlavor(ctx context.Context, name string) (*machinespec.MachineSpec, error) {
+	machineSpecs, err := c.machineSpecs.List(ctx, &machinespecservice.ListRequest{
+		Name: &name,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, el := range machineSpecs.GetItems() {
+		if el.GetName() == name {
+			return el, nil
+		}
+	}
+
+	return nil, fmt.Errorf("flavor %q %w", name, ErrNotFound)
+}
+
+// ResolveVersion returns the Kubernetes version with the given number, such as
+// "1.31.6". The version may be inactive, check IsActive before using it for
+// upgrades.
+func (c *Client) ResolveVersion(ctx context.Context, version string) (*kubernetesversion.KubernetesVersion, error) {
+	versions, err := c.versions.List(ctx, &kubernetesversionservice.ListRequest{
+		Version: &version,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, el := range versions.GetItems() {
+		if el.GetVersion() == version {
+			return el, nil
+		}
+	}
+
+	return nil, fmt.Errorf("version %q %w", version, ErrNotFound)
+}
+
+// ListVersions returns the Kubernetes versions offered by the service. Only
+// active versions are returned when activeOnly is set.
+func (c *Client) ListVersions(ctx context.Context, activeOnly bool) ([]*kubernetesversion.KubernetesVersion, error) {
+	req := &kubernetesversionservice.ListRequest{}
+	if activeOnly {
+		req.IsActive = &activeOnly
+	}
+
+	versions, err := c.versions.List(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return versions.GetItems(), nil
+}
@@ -0,0 +1,70 @@
+package client_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/cloudferro/terraform-provider-cloudferro/client"
+)
+
+func TestResolveFlavor(t *testing.T) {
+	ctx := testContext(t)
+	_, cli, _ := newTestClient(t)
+
+	tests := []struct {
+		name    string
+		flavor  string
+		wantErr error
+	}{
+		{name: "known", flavor: "eo2a.xlarge"},
+		{name: "unknown", flavor: "eo1.small", wantErr: client.ErrNotFound},
+		{name: "prefix of a known one", flavor: "eo2a", wantErr: client.ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := cli.ResolveFlavor(ctx, tt.flavor)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ResolveFlavor() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+
+			if got.GetName() != tt.flavor || got.GetId() == "" {
+				t.Errorf("ResolveFlavor() = %q (%q), want %q", got.GetName(), got.GetId(), tt.flavor)
+			}
+		})
+	}
+}
+
+func TestResolveVersion(t *testing.T) {
+	ctx := testContext(t)
+	_, cli, _ := newTestClient(t)
+
+	tests := []struct {
+		name    string
+		version string
+		wantErr error
+	}{
+		{name: "known", version: "1.31.6"},
+		{name: "unknown", version: "1.32.0", wantErr: client.ErrNotFound},
+		{name: "minor only", version: "1.31", wantErr: client.ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := cli.ResolveVersion(ctx, tt.version)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ResolveVersion() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+
+			if got.GetVersion() != tt.version || got.GetId() == "" {
+				t.Errorf("ResolveVersion() = %q (%q), want %q", got.GetVersion(), got.GetId(), tt.version)
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+// Package client is a Go client for the CloudFerro Managed Kubernetes API.
+//
+// It wraps the generated gRPC stubs with the operations needed to manage
+// clusters and node pools: looking up flavors and versions by name, creating
+// and changing objects, and waiting for the asynchronous operations started by
+// the service to finish.
+//
+//	conn, err := grpc.NewClient(host, ...)
+//	cli := client.New(conn, client.Options{})
+//
+//	klaster, err := cli.CreateCluster(ctx, client.ClusterSpec{
+//		Name:             "example",
+//		Version:          "1.31.6",
+//		Flavor:           "eo2a.large",
+//		ControlPlaneSize: 3,
+//	}, nil)
+package client
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"gitlab.cloudferro.com/k8s/api/clusterservice/v1"
+	"gitlab.cloudferro.com/k8s/api/kubernetesversionservice/v1"
+	"gitlab.cloudferro.com/k8s/api/machinespecservice/v1"
+	"gitlab.cloudferro.com/k8s/api/nodepoolservice/v1"
+	"google.golang.org/grpc"
+)
+
+// Status is the status of a cluster or a node pool.
+type Status string
+
+const (
+	StatusCreating Status = "Creating"
+	StatusRunning  Status = "Running"
+	StatusUpdating Status = "Updating"
+	StatusDeleting Status = "Deleting"
+	StatusError    Status = "Error"
+)
+
+// Settled reports whether no operation is in progress, the object being either
+// running or broken.
+func (s Status) Settled() bool {
+	return s == StatusRunning || s == StatusError
+}
+
+// ErrNotFound is returned, wrapped, when a flavor, version, cluster or node pool
+// looked up by name does not exist.
+var ErrNotFound = errors.New("not found")
+
+// OperationError is returned when an operation ends with the object in the
+// Error state. Message is the latest error reported for the cluster.
+type OperationError struct {
+	// Object is "cluster" or "node pool".
+	Object  string
+	ID      string
+	Message string
+}
+
+// Error implements error.
+func (e *OperationError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("%s %s is in the Error state", e.Object, e.ID)
+	}
+
+	return e.Message
+}
+
+// Options configure the client.
+type Options struct {
+	// PollInterval is how often the status is checked while waiting for an
+	// operation to finish. Defaults to 10 seconds.
+	PollInterval time.Duration
+	// MinBackoff and MaxBackoff bound the delay between retries of node pool
+	// calls rejected because another operation is in progress on the cluster.
+	// Default to 5 seconds and 1 minute.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	// Logger receives the progress of the operations, such as retries and
+	// waits. Defaults to discarding it.
+	Logger *slog.Logger
+}
+
+// Client performs operations on clusters and node pools. It is safe for
+// concurrent use.
+type Client struct {
+	opts Options
+
+	clusters     clusterservice.ClusterClient
+	nodePools    nodepoolservice.NodePoolClient
+	versions     kubernetesversionservice.KubernetesVersionClient
+	machineSpecs machinespecservice.MachineSpecClient
+}
+
+// New returns a client using conn.
+func New(conn grpc.ClientConnInterface, opts Options) *Client {
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = 10 * time.Second
+	}
+
+	if opts.MinBackoff <= 0 {
+		opts.MinBackoff = 5 * time.Second
+	}
+
+	if opts.MaxBackoff < opts.MinBackoff {
+		opts.MaxBackoff = max(time.Minute, opts.MinBackoff)
+	}
+
+	if opts.Logger == nil {
+		opts.Logger = slog.New(slog.DiscardHandler)
+	}
+
+	return &Client{
+		opts:         opts,
+		clusters:     clusterservice.NewClusterClient(conn),
+		nodePools:    nodepoolservice.NewNodePoolClient(conn),
+		versions:     kubernetesversionservice.NewKubernetesVersionClient(conn),
+		machineSpecs: machinespecservice.NewMachineSpecClient(conn),
+	}
+}
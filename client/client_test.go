@@ -0,0 +1,55 @@
+package client_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cloudferro/terraform-provider-cloudferro/client"
+	"github.com/cloudferro/terraform-provider-cloudferro/internal/fakeapi"
+	"gitlab.cloudferro.com/k8s/api/cluster/v1"
+	"google.golang.org/grpc"
+)
+
+const testTransitionDelay = 20 * time.Millisecond
+
+// newTestClient returns a client of a new fake service, polling and retrying
+// without delay.
+func newTestClient(t *testing.T) (*fakeapi.Server, *client.Client, grpc.ClientConnInterface) {
+	t.Helper()
+
+	srv := fakeapi.New(fakeapi.Options{TransitionDelay: testTransitionDelay})
+	conn := srv.ClientConn()
+
+	return srv, client.New(conn, client.Options{
+		PollInterval: time.Millisecond,
+		// smaller than the jitter granularity
+		MinBackoff: time.Nanosecond,
+		MaxBackoff: time.Millisecond,
+	}), conn
+}
+
+func testContext(t *testing.T) context.Context {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	t.Cleanup(cancel)
+
+	return ctx
+}
+
+func createTestCluster(ctx context.Context, t *testing.T, cli *client.Client) *cluster.Cluster {
+	t.Helper()
+
+	klaster, err := cli.CreateCluster(ctx, client.ClusterSpec{
+		Name:             "test",
+		Version:          "1.30.10",
+		Flavor:           "eo2a.large",
+		ControlPlaneSize: 1,
+	}, nil)
+	if err != nil {
+		t.Fatalf("CreateCluster() error = %v", err)
+	}
+
+	return klaster
+}
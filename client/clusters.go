@@ -0,0 +1,301 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"gitlab.cloudferro.com/k8s/api/cluster/v1"
+	"gitlab.cloudferro.com/k8s/api/clusterservice/v1"
+	cferror "gitlab.cloudferro.com/k8s/api/error/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ClusterSpec describes a cluster to create.
+type ClusterSpec struct {
+	Name string
+	// Version is the Kubernetes version, such as "1.31.6".
+	Version string
+	// Flavor is the name of the machine spec of the control plane nodes.
+	Flavor           string
+	ControlPlaneSize int32
+}
+
+// ClusterProgress is called with the current state of a cluster while waiting
+// for an operation to finish.
+type ClusterProgress func(*cluster.Cluster)
+
+// GetCluster returns the cluster, including the errors reported for it.
+func (c *Client) GetCluster(ctx context.Context, clusterID string) (*cluster.Cluster, error) {
+	return c.clusters.GetCluster(ctx, &clusterservice.GetClusterRequest{
+		ClusterId:   clusterID,
+		ExtraFields: "errors",
+	})
+}
+
+// ListClusters returns all clusters of the project.
+func (c *Client) ListClusters(ctx context.Context) ([]*cluster.Cluster, error) {
+	clusters, err := c.clusters.ListClusters(ctx, &clusterservice.ListClustersRequest{})
+	if err != nil {
+		return nil, err
+	}
+
+	return clusters.GetItems(), nil
+}
+
+// ResolveClusterID returns the id of the cluster identified either by its id
+// or by its name.
+func (c *Client) ResolveClusterID(ctx context.Context, nameOrID string) (string, error) {
+	if _, err := uuid.Parse(nameOrID); err == nil {
+		return nameOrID, nil
+	}
+
+	clusters, err := c.ListClusters(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	var ids []string
+	for _, el := range clusters {
+		if el.GetName() == nameOrID {
+			ids = append(ids, el.GetId())
+		}
+	}
+
+	switch len(ids) {
+	case 0:
+		return "", fmt.Errorf("cluster %q %w", nameOrID, ErrNotFound)
+	case 1:
+		return ids[0], nil
+	default:
+		return "", fmt.Errorf(
+			"cluster name %q is ambiguous, it matches clusters %s; use the cluster id instead",
+			nameOrID, strings.Join(ids, ", "),
+		)
+	}
+}
+
+// Kubeconfig returns the kubeconfig of a running cluster.
+func (c *Client) Kubeconfig(ctx context.Context, clusterID string) (string, error) {
+	files, err := c.clusters.GetClusterFiles(ctx, &clusterservice.GetClusterFilesRequest{
+		ClusterId: clusterID,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return files.GetKubeconfig(), nil
+}
+
+// LatestClusterError returns the most recent error reported for the cluster,
+// or nil when there is none.
+func (c *Client) LatestClusterError(ctx context.Context, clusterID string) (*cferror.Error, error) {
+	klaster, err := c.GetCluster(ctx, clusterID)
+	if err != nil {
+		return nil, err
+	}
+
+	return LatestError(klaster), nil
+}
+
+// LatestError returns the most recent error reported for the cluster, or nil
+// when there is none. The cluster must be retrieved with GetCluster, which
+// includes the errors.
+func LatestError(klaster *cluster.Cluster) *cferror.Error {
+	var latest *cferror.Error
+	for _, el := range klaster.GetErrors() {
+		if latest == nil || latest.GetCreatedAt().AsTime().Before(el.GetCreatedAt().AsTime()) {
+			latest = el
+		}
+	}
+
+	return latest
+}
+
+// operationError returns the error for an object of the cluster which ended
+// in the Error state.
+func (c *Client) operationError(ctx context.Context, object, id, clusterID string) error {
+	lastErr, err := c.LatestClusterError(ctx, clusterID)
+	if err != nil {
+		return err
+	}
+
+	return &OperationError{Object: object, ID: id, Message: lastErr.GetMsg()}
+}
+
+// CreateCluster creates a cluster and waits until it is running. progress, if
+// not nil, is called once the cluster is created and on every status check.
+func (c *Client) CreateCluster(ctx context.Context, spec ClusterSpec, progress ClusterProgress) (*cluster.Cluster, error) {
+	machineSpec, err := c.ResolveFlavor(ctx, spec.Flavor)
+	if err != nil {
+		return nil, err
+	}
+
+	version, err := c.ResolveVersion(ctx, spec.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	klaster, err := c.clusters.CreateCluster(ctx, &clusterservice.CreateClusterRequest{
+		Cluster: &clusterservice.CreateCluster{
+			Name: spec.Name,
+			KubernetesVersion: &clusterservice.CreateCluster_KubernetesVersion{
+				Id: version.GetId(),
+			},
+			ControlPlane: &clusterservice.CreateCluster_ControlPlane{
+				Value: &clusterservice.CreateCluster_ControlPlane_Custom{
+					Custom: &clusterservice.CreateCluster_ControlPlaneCustom{
+						Size: spec.ControlPlaneSize,
+						MachineSpec: &clusterservice.CreateCluster_MachineSpec{
+							Id: machineSpec.GetId(),
+						},
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if progress != nil {
+		progress(klaster)
+	}
+
+	return c.WaitForCluster(ctx, klaster.GetId(), progress)
+}
+
+// UpgradeCluster changes the Kubernetes version of the cluster and waits until
+// it is running again. The upgrade starts once no node pool operation is in
+// progress.
+func (c *Client) UpgradeCluster(
+	ctx context.Context,
+	clusterID, version string,
+	progress ClusterProgress,
+) (*cluster.Cluster, error) {
+	kubernetesVersion, err := c.ResolveVersion(ctx, version)
+	if err != nil {
+		return nil, err
+	}
+
+	if !kubernetesVersion.GetIsActive() {
+		return nil, fmt.Errorf("version %q is no longer offered", version)
+	}
+
+	klaster, err := c.clusters.GetCluster(ctx, &clusterservice.GetClusterRequest{ClusterId: clusterID})
+	if err != nil {
+		return nil, err
+	}
+
+	klaster.Version = kubernetesVersion
+
+	// upgrading the control plane while node pools are being changed leaves
+	// them in the Error state
+	if err := c.WaitForNodePoolsSettled(ctx, clusterID); err != nil {
+		return nil, err
+	}
+
+	c.opts.Logger.InfoContext(ctx, "updating cluster",
+		"cluster_id", clusterID,
+		"version", version,
+	)
+	_, err = c.clusters.UpdateCluster(ctx, &clusterservice.UpdateClusterRequest{
+		ClusterId: clusterID,
+		Update:    klaster,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return c.WaitForCluster(ctx, clusterID, progress)
+}
+
+// DeleteCluster deletes the cluster and waits until it is gone.
+func (c *Client) DeleteCluster(ctx context.Context, clusterID string) error {
+	_, err := c.clusters.DeleteCluster(ctx, &clusterservice.DeleteClusterRequest{
+		ClusterId: clusterID,
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.poll(ctx, func() (bool, string, error) {
+		klaster, err := c.GetCluster(ctx, clusterID)
+		if status.Code(err) == codes.NotFound {
+			return true, "", nil
+		} else if err != nil {
+			return false, "", err
+		}
+
+		if Status(klaster.GetStatus()) == StatusError {
+			return false, "", &OperationError{Object: "cluster", ID: clusterID, Message: LatestError(klaster).GetMsg()}
+		}
+
+		return false, "cluster is still being deleted", nil
+	})
+}
+
+// WaitForCluster waits until the cluster is running. It returns an
+// OperationError when the cluster ends in the Error state.
+func (c *Client) WaitForCluster(
+	ctx context.Context,
+	clusterID string,
+	progress ClusterProgress,
+) (*cluster.Cluster, error) {
+	var klaster *cluster.Cluster
+
+	err := c.poll(ctx, func() (bool, string, error) {
+		var err error
+		klaster, err = c.GetCluster(ctx, clusterID)
+		if err != nil {
+			return false, "", err
+		}
+
+		if progress != nil {
+			progress(klaster)
+		}
+
+		switch Status(klaster.GetStatus()) {
+		case StatusRunning:
+			return true, "", nil
+		case StatusError:
+			return false, "", &OperationError{Object: "cluster", ID: clusterID, Message: LatestError(klaster).GetMsg()}
+		}
+
+		return false, fmt.Sprintf("cluster is still in the %q state", klaster.GetStatus()), nil
+	})
+
+	return klaster, err
+}
+
+// WaitForClusterSettled waits while the cluster is in a transitional state
+// ("Creating", "Updating", ...) in which it does not accept node pool changes.
+// It returns the settled status, either StatusRunning or StatusError.
+func (c *Client) WaitForClusterSettled(ctx context.Context, clusterID string) (Status, error) {
+	var current Status
+
+	err := c.poll(ctx, func() (bool, string, error) {
+		klaster, err := c.clusters.GetCluster(ctx, &clusterservice.GetClusterRequest{
+			ClusterId: clusterID,
+		})
+		if err != nil {
+			return false, "", err
+		}
+
+		current = Status(klaster.GetStatus())
+		if current.Settled() {
+			return true, "", nil
+		}
+
+		c.opts.Logger.InfoContext(ctx, "waiting for the cluster to accept node pool changes",
+			"cluster_id", clusterID,
+			"status", current,
+		)
+
+		return false, fmt.Sprintf("cluster is still in the %q state", current), nil
+	})
+
+	return current, err
+}
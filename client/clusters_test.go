@@ -0,0 +1,107 @@
+package client_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/cloudferro/terraform-provider-cloudferro/client"
+	"gitlab.cloudferro.com/k8s/api/cluster/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestCreateCluster(t *testing.T) {
+	ctx := testContext(t)
+	_, cli, _ := newTestClient(t)
+
+	var seen []client.Status
+	klaster, err := cli.CreateCluster(ctx, client.ClusterSpec{
+		Name:             "test",
+		Version:          "1.31.6",
+		Flavor:           "eo2a.large",
+		ControlPlaneSize: 3,
+	}, func(klaster *cluster.Cluster) {
+		seen = append(seen, client.Status(klaster.GetStatus()))
+	})
+	if err != nil {
+		t.Fatalf("CreateCluster() error = %v", err)
+	}
+
+	if klaster.GetName() != "test" || client.Status(klaster.GetStatus()) != client.StatusRunning {
+		t.Errorf("CreateCluster() = %q in the %q state, want test running", klaster.GetName(), klaster.GetStatus())
+	}
+	if len(seen) < 2 || seen[0] != client.StatusCreating || seen[len(seen)-1] != client.StatusRunning {
+		t.Errorf("CreateCluster() progress = %v, want Creating to Running", seen)
+	}
+}
+
+func TestCreateCluster_errorState(t *testing.T) {
+	ctx := testContext(t)
+	srv, cli, _ := newTestClient(t)
+
+	srv.InjectErrorState("CreateCluster", "no capacity")
+
+	var id string
+	_, err := cli.CreateCluster(ctx, client.ClusterSpec{
+		Name:             "test",
+		Version:          "1.31.6",
+		Flavor:           "eo2a.large",
+		ControlPlaneSize: 1,
+	}, func(klaster *cluster.Cluster) {
+		id = klaster.GetId()
+	})
+
+	var opErr *client.OperationError
+	if !errors.As(err, &opErr) {
+		t.Fatalf("CreateCluster() error = %v, want an OperationError", err)
+	}
+	if opErr.Object != "cluster" || opErr.ID != id || opErr.Message != "no capacity" {
+		t.Errorf("CreateCluster() error = %+v, want no capacity of cluster %s", opErr, id)
+	}
+}
+
+func TestCreateCluster_unknownFlavor(t *testing.T) {
+	ctx := testContext(t)
+	_, cli, _ := newTestClient(t)
+
+	_, err := cli.CreateCluster(ctx, client.ClusterSpec{
+		Name:             "test",
+		Version:          "1.31.6",
+		Flavor:           "eo1.small",
+		ControlPlaneSize: 1,
+	}, nil)
+	if !errors.Is(err, client.ErrNotFound) {
+		t.Fatalf("CreateCluster() error = %v, want ErrNotFound", err)
+	}
+
+	if clusters, _ := cli.ListClusters(ctx); len(clusters) != 0 {
+		t.Errorf("CreateCluster() created %d clusters, want none", len(clusters))
+	}
+}
+
+func TestWaitForCluster(t *testing.T) {
+	ctx := testContext(t)
+	_, cli, _ := newTestClient(t)
+	klaster := createTestCluster(ctx, t, cli)
+
+	tests := []struct {
+		name      string
+		clusterID string
+		wantCode  codes.Code
+	}{
+		{name: "running", clusterID: klaster.GetId(), wantCode: codes.OK},
+		{name: "unknown", clusterID: "00000000-0000-0000-0000-000000000000", wantCode: codes.NotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := cli.WaitForCluster(ctx, tt.clusterID, nil)
+			if status.Code(err) != tt.wantCode {
+				t.Fatalf("WaitForCluster() error = %v, want %s", err, tt.wantCode)
+			}
+			if err == nil && got.GetId() != tt.clusterID {
+				t.Errorf("WaitForCluster() = %q, want %q", got.GetId(), tt.clusterID)
+			}
+		})
+	}
+}
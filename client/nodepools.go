@@ -0,0 +1,557 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+	"gitlab.cloudferro.com/k8s/api/nodepool/v1"
+	"gitlab.cloudferro.com/k8s/api/nodepoolservice/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// maxNodePoolNameLength is the longest node pool name the service accepts.
+const maxNodePoolNameLength = 64
+
+// NodePoolSpec describes a node pool. Either Size or SizeMin and SizeMax are
+// set, the latter when Autoscale is on.
+type NodePoolSpec struct {
+	Name string
+	// Flavor is the name of the machine spec of the nodes.
+	Flavor    string
+	Autoscale bool
+	Size      *int32
+	SizeMin   *int32
+	SizeMax   *int32
+	// SharedNetworks are ids of networks attached to the nodes. On update a
+	// nil slice leaves the networks unchanged, an empty one detaches them all.
+	SharedNetworks []string
+	Labels         []*nodepool.Label
+	Taints         []*nodepool.Taint
+}
+
+// NodePoolProgress is called with the current state of a node pool while
+// waiting for an operation to finish.
+type NodePoolProgress func(*nodepool.NodePool)
+
+// GetNodePool returns the node pool.
+func (c *Client) GetNodePool(ctx context.Context, clusterID, nodePoolID string) (*nodepool.NodePool, error) {
+	return c.nodePools.GetNodePool(ctx, &nodepoolservice.GetNodePoolRequest{
+		ClusterId:  clusterID,
+		NodePoolId: nodePoolID,
+	})
+}
+
+// ListNodePools returns all node pools of the cluster.
+func (c *Client) ListNodePools(ctx context.Context, clusterID string) ([]*nodepool.NodePool, error) {
+	nodePools, err := c.nodePools.ListNodePools(ctx, &nodepoolservice.ListNodePoolsRequest{
+		ClusterId: clusterID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return nodePools.GetItems(), nil
+}
+
+// ResolveNodePoolID returns the id of the node pool of the cluster identified
+// either by its id or by its name.
+func (c *Client) ResolveNodePoolID(ctx context.Context, clusterID, nameOrID string) (string, error) {
+	if _, err := uuid.Parse(nameOrID); err == nil {
+		return nameOrID, nil
+	}
+
+	nodePools, err := c.ListNodePools(ctx, clusterID)
+	if err != nil {
+		return "", err
+	}
+
+	var ids []string
+	for _, el := range nodePools {
+		if el.GetName() == nameOrID {
+			ids = append(ids, el.GetId())
+		}
+	}
+
+	switch len(ids) {
+	case 0:
+		return "", fmt.Errorf("node pool %q %w in cluster %s", nameOrID, ErrNotFound, clusterID)
+	case 1:
+		return ids[0], nil
+	default:
+		return "", fmt.Errorf(
+			"node pool name %q is ambiguous, it matches node pools %s; use the node pool id instead",
+			nameOrID, strings.Join(ids, ", "),
+		)
+	}
+}
+
+// CreateNodePool creates a node pool and waits until it is running. progress,
+// if not nil, is called once the node pool is created and on every status
+// check.
+func (c *Client) CreateNodePool(
+	ctx context.Context,
+	clusterID string,
+	spec NodePoolSpec,
+	progress NodePoolProgress,
+) (*nodepool.NodePool, error) {
+	machineSpec, err := c.ResolveFlavor(ctx, spec.Flavor)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.waitForClusterRunning(ctx, clusterID); err != nil {
+		return nil, err
+	}
+
+	var nodePool *nodepool.NodePool
+	err = c.submit(ctx, clusterID, func(ctx context.Context) error {
+		var err error
+		nodePool, err = c.nodePools.CreateNodePool(ctx, &nodepoolservice.CreateNodePoolRequest{
+			ClusterId: clusterID,
+			NodePool: &nodepoolservice.NodePoolCreate{
+				MachineSpec: &nodepoolservice.NodePoolCreate_MachineSpec{
+					Id: machineSpec.GetId(),
+				},
+				Name:           &spec.Name,
+				Size:           spec.Size,
+				SizeMin:        spec.SizeMin,
+				SizeMax:        spec.SizeMax,
+				Autoscale:      spec.Autoscale,
+				SharedNetworks: spec.SharedNetworks,
+				Labels:         spec.Labels,
+				Taints:         spec.Taints,
+			},
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if progress != nil {
+		progress(nodePool)
+	}
+
+	return c.WaitForNodePool(ctx, clusterID, nodePool.GetId(), progress)
+}
+
+// UpdateNodePool changes the size, autoscaling and shared networks of the node
+// pool and waits until it is running again. The other fields of spec are
+// ignored, they cannot be changed in place.
+func (c *Client) UpdateNodePool(
+	ctx context.Context,
+	clusterID, nodePoolID string,
+	spec NodePoolSpec,
+	progress NodePoolProgress,
+) (*nodepool.NodePool, error) {
+	if err := c.waitForClusterRunning(ctx, clusterID); err != nil {
+		return nil, err
+	}
+
+	nodePool, err := c.GetNodePool(ctx, clusterID, nodePoolID)
+	if err != nil {
+		return nil, err
+	}
+
+	nodePool.Autoscale = spec.Autoscale
+	nodePool.Size = spec.Size
+	nodePool.SizeMin = spec.SizeMin
+	nodePool.SizeMax = spec.SizeMax
+	if spec.SharedNetworks != nil {
+		// network changes are rolled out by the service node by node, the
+		// pool goes through "Updating" like for any other change
+		nodePool.SharedNetworks = spec.SharedNetworks
+	}
+
+	c.opts.Logger.InfoContext(ctx, "updating node pool",
+		"cluster_id", clusterID,
+		"node_pool_id", nodePoolID,
+	)
+	err = c.submit(ctx, clusterID, func(ctx context.Context) error {
+		_, err := c.nodePools.UpdateNodePool(ctx, &nodepoolservice.UpdateNodePoolRequest{
+			ClusterId:  clusterID,
+			NodePoolId: nodePoolID,
+			NodePool:   nodePool,
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return c.WaitForNodePool(ctx, clusterID, nodePoolID, progress)
+}
+
+// surgeSuffix is appended to the name of the temporary node pool the nodes of
+// a node pool are rolled to while it is replaced.
+const surgeSuffix = "-surge"
+
+// ReplaceNodePool rolls out the size, autoscaling and shared networks of spec
+// by replacing the nodes of the node pool one at a time, for the changes the
+// service cannot make in place. The service has no node level operations and
+// node pool names are unique, so the nodes are rolled to a temporary node pool
+// with spec, the node pool is deleted, and the nodes are rolled back to a node
+// pool created again with its name. A node is always added before one is
+// removed, so the node pool never has more than one node above its size.
+//
+// The node pool gets a new id. progress, if not nil, is called with the
+// temporary node pool before the node pool is deleted and with the new node
+// pool from its creation on, so that the caller never tracks a deleted node
+// pool. The other fields of spec are ignored, like in UpdateNodePool.
+func (c *Client) ReplaceNodePool(
+	ctx context.Context,
+	clusterID, nodePoolID string,
+	spec NodePoolSpec,
+	progress NodePoolProgress,
+) (*nodepool.NodePool, error) {
+	existing, err := c.GetNodePool(ctx, clusterID, nodePoolID)
+	if err != nil {
+		return nil, err
+	}
+
+	name := existing.GetName()
+	size := replacementSize(existing, spec)
+	one := int32(1)
+	surge := NodePoolSpec{
+		Name:           name[:min(len(name), maxNodePoolNameLength-len(surgeSuffix))] + surgeSuffix,
+		Flavor:         existing.GetMachineSpec().GetName(),
+		Size:           &one,
+		SharedNetworks: spec.SharedNetworks,
+		Labels:         existing.GetLabels(),
+		Taints:         existing.GetTaints(),
+	}
+	if surge.SharedNetworks == nil {
+		surge.SharedNetworks = existing.GetSharedNetworks()
+	}
+
+	c.opts.Logger.InfoContext(ctx, "replacing node pool",
+		"cluster_id", clusterID,
+		"node_pool_id", nodePoolID,
+		"surge", surge.Name,
+		"size", size,
+	)
+
+	surgePool, err := c.CreateNodePool(ctx, clusterID, surge, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create surge node pool %q: %w", surge.Name, err)
+	}
+
+	surgePool, err = c.rollNodes(ctx, clusterID, existing, surgePool, size, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to roll nodes to surge node pool %q: %w", surge.Name, err)
+	}
+
+	if progress != nil {
+		progress(surgePool)
+	}
+
+	if err := c.DeleteNodePool(ctx, clusterID, nodePoolID); err != nil {
+		return surgePool, fmt.Errorf(
+			"failed to delete node pool, its nodes are in surge node pool %q: %w", surge.Name, err,
+		)
+	}
+
+	replacement := surge
+	replacement.Name = name
+	nodePool, err := c.CreateNodePool(ctx, clusterID, replacement, progress)
+	if err != nil {
+		return surgePool, fmt.Errorf(
+			"failed to create node pool again, its nodes are in surge node pool %q: %w", surge.Name, err,
+		)
+	}
+
+	nodePool, err = c.rollNodes(ctx, clusterID, surgePool, nodePool, size, progress)
+	if err != nil {
+		return nodePool, fmt.Errorf("failed to roll nodes back from surge node pool %q: %w", surge.Name, err)
+	}
+
+	if err := c.DeleteNodePool(ctx, clusterID, surgePool.GetId()); err != nil {
+		return nodePool, fmt.Errorf("failed to delete surge node pool %q: %w", surge.Name, err)
+	}
+
+	if !spec.Autoscale {
+		return nodePool, nil
+	}
+
+	return c.UpdateNodePool(ctx, clusterID, nodePool.GetId(), NodePoolSpec{
+		Autoscale: spec.Autoscale,
+		Size:      spec.Size,
+		SizeMin:   spec.SizeMin,
+		SizeMax:   spec.SizeMax,
+	}, progress)
+}
+
+// rollNodes grows the node pool to by one node at a time until it has size
+// nodes, shrinking the node pool from by one node after each step. from keeps
+// a single node at least, the caller deletes it. It returns the grown node
+// pool.
+func (c *Client) rollNodes(
+	ctx context.Context,
+	clusterID string,
+	from, to *nodepool.NodePool,
+	size int32,
+	progress NodePoolProgress,
+) (*nodepool.NodePool, error) {
+	fromSize := nodePoolSize(from)
+	for toSize := nodePoolSize(to); toSize < size; {
+		toSize++
+		grown, err := c.UpdateNodePool(ctx, clusterID, to.GetId(), NodePoolSpec{Size: &toSize}, progress)
+		if err != nil {
+			return to, err
+		}
+		to = grown
+
+		if fromSize > 1 {
+			fromSize--
+			if _, err := c.UpdateNodePool(ctx, clusterID, from.GetId(), NodePoolSpec{Size: &fromSize}, nil); err != nil {
+				return to, err
+			}
+		}
+	}
+
+	return to, nil
+}
+
+// nodePoolSize returns the number of nodes of the node pool, at least one.
+func nodePoolSize(nodePool *nodepool.NodePool) int32 {
+	if !nodePool.GetAutoscale() && nodePool.Size != nil {
+		return max(nodePool.GetSize(), 1)
+	}
+
+	return max(int32(len(nodePool.GetNodes())), 1) //nolint:gosec // node counts are small
+}
+
+// replacementSize returns the number of nodes the replacement of the node pool
+// starts with: the size of spec, or for an autoscaled one the current number of
+// nodes within its bounds.
+func replacementSize(existing *nodepool.NodePool, spec NodePoolSpec) int32 {
+	if !spec.Autoscale && spec.Size != nil {
+		return max(*spec.Size, 1)
+	}
+
+	size := nodePoolSize(existing)
+	if spec.SizeMin != nil {
+		size = max(size, *spec.SizeMin)
+	}
+	if spec.SizeMax != nil {
+		size = min(size, *spec.SizeMax)
+	}
+
+	return max(size, 1)
+}
+
+// EnsureNodePool makes the cluster have a node pool named spec.Name matching
+// spec. The node pool is created when missing, otherwise its size,
+// autoscaling and shared networks are updated when they differ. It returns an
+// error when the flavor, labels or taints differ, as they cannot be changed in
+// place.
+func (c *Client) EnsureNodePool(
+	ctx context.Context,
+	clusterID string,
+	spec NodePoolSpec,
+	progress NodePoolProgress,
+) (*nodepool.NodePool, error) {
+	nodePools, err := c.ListNodePools(ctx, clusterID)
+	if err != nil {
+		return nil, err
+	}
+
+	var existing *nodepool.NodePool
+	for _, el := range nodePools {
+		if el.GetName() == spec.Name {
+			existing = el
+			break
+		}
+	}
+
+	if existing == nil {
+		return c.CreateNodePool(ctx, clusterID, spec, progress)
+	}
+
+	if existing.GetMachineSpec().GetName() != spec.Flavor {
+		return nil, fmt.Errorf(
+			"node pool %q uses flavor %q, the flavor cannot be changed in place",
+			spec.Name, existing.GetMachineSpec().GetName(),
+		)
+	}
+
+	if !slices.EqualFunc(existing.GetLabels(), spec.Labels, labelsEqual) ||
+		!slices.EqualFunc(existing.GetTaints(), spec.Taints, taintsEqual) {
+		return nil, fmt.Errorf("node pool %q has different labels or taints, they cannot be changed in place", spec.Name)
+	}
+
+	if existing.GetAutoscale() == spec.Autoscale &&
+		int32PtrEqual(existing.Size, spec.Size) &&
+		int32PtrEqual(existing.SizeMin, spec.SizeMin) &&
+		int32PtrEqual(existing.SizeMax, spec.SizeMax) &&
+		(spec.SharedNetworks == nil || slices.Equal(existing.GetSharedNetworks(), spec.SharedNetworks)) {
+		return c.WaitForNodePool(ctx, clusterID, existing.GetId(), progress)
+	}
+
+	return c.UpdateNodePool(ctx, clusterID, existing.GetId(), spec, progress)
+}
+
+// DeleteNodePool deletes the node pool and waits until it is gone. Deleting a
+// node pool which does not exist is not an error.
+func (c *Client) DeleteNodePool(ctx context.Context, clusterID, nodePoolID string) error {
+	nodePool, err := c.GetNodePool(ctx, clusterID, nodePoolID)
+	if status.Code(err) == codes.NotFound {
+		c.opts.Logger.InfoContext(ctx, "node pool not found, nothing to delete",
+			"cluster_id", clusterID,
+			"node_pool_id", nodePoolID,
+		)
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	switch Status(nodePool.GetStatus()) {
+	case StatusRunning, StatusError:
+		c.opts.Logger.DebugContext(ctx, "node pool is running or in error, deleting it",
+			"cluster_id", clusterID,
+			"node_pool_id", nodePoolID,
+		)
+		_, err = c.WaitForClusterSettled(ctx, clusterID)
+		if status.Code(err) == codes.NotFound {
+			c.opts.Logger.InfoContext(ctx, "cluster not found, nothing to delete", "cluster_id", clusterID)
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		err = c.submit(ctx, clusterID, func(ctx context.Context) error {
+			_, err := c.nodePools.DeleteNodePool(ctx, &nodepoolservice.DeleteNodePoolRequest{
+				ClusterId:  clusterID,
+				NodePoolId: nodePoolID,
+			})
+			return err
+		})
+		if status.Code(err) == codes.NotFound {
+			c.opts.Logger.InfoContext(ctx, "node pool not found, nothing to delete",
+				"cluster_id", clusterID,
+				"node_pool_id", nodePoolID,
+			)
+			return nil
+		} else if err != nil {
+			return err
+		}
+	case StatusDeleting:
+	default:
+		return c.operationError(ctx, "node pool", nodePoolID, clusterID)
+	}
+
+	return c.poll(ctx, func() (bool, string, error) {
+		nodePool, err := c.GetNodePool(ctx, clusterID, nodePoolID)
+		if status.Code(err) == codes.NotFound {
+			return true, "", nil
+		} else if err != nil {
+			return false, "", err
+		}
+
+		if Status(nodePool.GetStatus()) == StatusError {
+			return false, "", c.operationError(ctx, "node pool", nodePoolID, clusterID)
+		}
+
+		c.opts.Logger.DebugContext(ctx, "node pool is still deleting",
+			"cluster_id", clusterID,
+			"node_pool_id", nodePoolID,
+		)
+		return false, "node pool is still being deleted", nil
+	})
+}
+
+// WaitForNodePool waits until the node pool is running. It returns an
+// OperationError when the node pool ends in the Error state.
+func (c *Client) WaitForNodePool(
+	ctx context.Context,
+	clusterID, nodePoolID string,
+	progress NodePoolProgress,
+) (*nodepool.NodePool, error) {
+	var nodePool *nodepool.NodePool
+
+	err := c.poll(ctx, func() (bool, string, error) {
+		var err error
+		nodePool, err = c.GetNodePool(ctx, clusterID, nodePoolID)
+		if err != nil {
+			return false, "", err
+		}
+
+		if progress != nil {
+			progress(nodePool)
+		}
+
+		switch Status(nodePool.GetStatus()) {
+		case StatusRunning:
+			return true, "", nil
+		case StatusError:
+			return false, "", c.operationError(ctx, "node pool", nodePoolID, clusterID)
+		}
+
+		return false, fmt.Sprintf("node pool is still in the %q state", nodePool.GetStatus()), nil
+	})
+
+	return nodePool, err
+}
+
+// WaitForNodePoolsSettled waits until none of the node pools of the cluster has
+// an operation in progress.
+func (c *Client) WaitForNodePoolsSettled(ctx context.Context, clusterID string) error {
+	return c.poll(ctx, func() (bool, string, error) {
+		nodePools, err := c.ListNodePools(ctx, clusterID)
+		if err != nil {
+			return false, "", err
+		}
+
+		var busy []string
+		for _, el := range nodePools {
+			if !Status(el.GetStatus()).Settled() {
+				busy = append(busy, el.GetName())
+			}
+		}
+
+		if len(busy) == 0 {
+			return true, "", nil
+		}
+
+		c.opts.Logger.InfoContext(ctx, "waiting for node pool operations to finish",
+			"cluster_id", clusterID,
+			"node_pools", busy,
+		)
+
+		return false, fmt.Sprintf("node pools %v are still being changed", busy), nil
+	})
+}
+
+// waitForClusterRunning waits until the cluster accepts node pool changes.
+func (c *Client) waitForClusterRunning(ctx context.Context, clusterID string) error {
+	clusterStatus, err := c.WaitForClusterSettled(ctx, clusterID)
+	if err != nil {
+		return err
+	}
+
+	if clusterStatus == StatusError {
+		return fmt.Errorf("cluster is in the Error state")
+	}
+
+	return nil
+}
+
+func labelsEqual(a, b *nodepool.Label) bool {
+	return a.GetKey() == b.GetKey() && a.GetValue() == b.GetValue()
+}
+
+func taintsEqual(a, b *nodepool.Taint) bool {
+	return a.GetKey() == b.GetKey() && a.GetValue() == b.GetValue() && a.GetEffect() == b.GetEffect()
+}
+
+func int32PtrEqual(a, b *int32) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	return *a == *b
+}
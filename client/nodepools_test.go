@@ -0,0 +1,126 @@
+package client_test
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/cloudferro/terraform-provider-cloudferro/client"
+	"gitlab.cloudferro.com/k8s/api/nodepool/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestEnsureNodePool(t *testing.T) {
+	ctx := testContext(t)
+	srv, cli, _ := newTestClient(t)
+	klaster := createTestCluster(ctx, t, cli)
+
+	size := func(n int32) *int32 { return &n }
+	spec := client.NodePoolSpec{
+		Name:   "pool",
+		Flavor: "eo2a.large",
+		Size:   size(1),
+	}
+
+	created, err := cli.EnsureNodePool(ctx, klaster.GetId(), spec, nil)
+	if err != nil {
+		t.Fatalf("EnsureNodePool() error = %v", err)
+	}
+	if client.Status(created.GetStatus()) != client.StatusRunning {
+		t.Errorf("EnsureNodePool() status = %q, want Running", created.GetStatus())
+	}
+
+	// an update would fail
+	srv.InjectError("UpdateNodePool", status.Error(codes.Internal, "unexpected update"))
+	unchanged, err := cli.EnsureNodePool(ctx, klaster.GetId(), spec, nil)
+	if err != nil {
+		t.Fatalf("EnsureNodePool() of an unchanged node pool error = %v", err)
+	}
+	if unchanged.GetId() != created.GetId() {
+		t.Errorf("EnsureNodePool() = %q, want the existing node pool %q", unchanged.GetId(), created.GetId())
+	}
+	// the injected error is still pending
+	if _, err := cli.UpdateNodePool(ctx, klaster.GetId(), created.GetId(), spec, nil); status.Code(err) != codes.Internal {
+		t.Fatalf("UpdateNodePool() error = %v, want the injected one", err)
+	}
+
+	var seen []client.Status
+	spec.Size = size(2)
+	resized, err := cli.EnsureNodePool(ctx, klaster.GetId(), spec, func(nodePool *nodepool.NodePool) {
+		seen = append(seen, client.Status(nodePool.GetStatus()))
+	})
+	if err != nil {
+		t.Fatalf("EnsureNodePool() of a resized node pool error = %v", err)
+	}
+	if resized.GetId() != created.GetId() || resized.GetSize() != 2 {
+		t.Errorf("EnsureNodePool() = %q of size %d, want %q of size 2", resized.GetId(), resized.GetSize(), created.GetId())
+	}
+	if len(seen) == 0 || seen[len(seen)-1] != client.StatusRunning {
+		t.Errorf("EnsureNodePool() progress = %v, want to end Running", seen)
+	}
+
+	spec.Flavor = "eo2a.xlarge"
+	if _, err := cli.EnsureNodePool(ctx, klaster.GetId(), spec, nil); err == nil ||
+		!strings.Contains(err.Error(), "cannot be changed in place") {
+		t.Errorf("EnsureNodePool() of another flavor error = %v, want it cannot be changed in place", err)
+	}
+
+	nodePools, err := cli.ListNodePools(ctx, klaster.GetId())
+	if err != nil {
+		t.Fatalf("ListNodePools() error = %v", err)
+	}
+	if len(nodePools) != 1 {
+		t.Errorf("ListNodePools() = %d node pools, want 1", len(nodePools))
+	}
+}
+
+func TestReplaceNodePool(t *testing.T) {
+	ctx := testContext(t)
+	_, cli, _ := newTestClient(t)
+	klaster := createTestCluster(ctx, t, cli)
+
+	size := int32(3)
+	existing, err := cli.CreateNodePool(ctx, klaster.GetId(), client.NodePoolSpec{
+		Name:   "pool",
+		Flavor: "eo2a.large",
+		Size:   &size,
+	}, nil)
+	if err != nil {
+		t.Fatalf("CreateNodePool() error = %v", err)
+	}
+
+	var seen []string
+	network := "6f3d5a52-3b0e-4a38-9d4c-2a1d1c2b7e01"
+	replaced, err := cli.ReplaceNodePool(ctx, klaster.GetId(), existing.GetId(), client.NodePoolSpec{
+		Size:           &size,
+		SharedNetworks: []string{network},
+	}, func(nodePool *nodepool.NodePool) {
+		if len(seen) == 0 || seen[len(seen)-1] != nodePool.GetName() {
+			seen = append(seen, nodePool.GetName())
+		}
+	})
+	if err != nil {
+		t.Fatalf("ReplaceNodePool() error = %v", err)
+	}
+
+	if replaced.GetId() == existing.GetId() || replaced.GetName() != "pool" || replaced.GetSize() != size {
+		t.Errorf("ReplaceNodePool() = %q named %q of size %d, want a new pool of size %d",
+			replaced.GetId(), replaced.GetName(), replaced.GetSize(), size)
+	}
+	if !slices.Equal(replaced.GetSharedNetworks(), []string{network}) {
+		t.Errorf("ReplaceNodePool() shared networks = %v, want %s", replaced.GetSharedNetworks(), network)
+	}
+	// the surge node pool is tracked until the node pool is created again
+	if want := []string{"pool-surge", "pool"}; !slices.Equal(seen, want) {
+		t.Errorf("ReplaceNodePool() progress = %v, want %v", seen, want)
+	}
+
+	nodePools, err := cli.ListNodePools(ctx, klaster.GetId())
+	if err != nil {
+		t.Fatalf("ListNodePools() error = %v", err)
+	}
+	if len(nodePools) != 1 || nodePools[0].GetId() != replaced.GetId() {
+		t.Errorf("ListNodePools() = %d node pools, want only the replaced one", len(nodePools))
+	}
+}
@@ -0,0 +1,182 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"time"
+
+	"gitlab.cloudferro.com/k8s/api/clusterservice/v1"
+	"gitlab.cloudferro.com/k8s/api/nodepool/v1"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// clusterOperations orders submission of mutating node pool calls made by this
+// process. The per-cluster lock is held only while a call is submitted, waiting
+// for the node pool to settle happens outside of it, so pools of the same
+// cluster are processed in parallel. Conflicts with operations started
+// elsewhere (another terraform run or workspace, the panel) are detected from
+// the service response and the call is retried with a backoff.
+var clusterOperations = newOperationCoordinator()
+
+// tracer records the waits of the client, for lock and status transitions.
+var tracer = otel.Tracer("github.com/cloudferro/terraform-provider-cloudferro/client")
+
+type operationCoordinator struct {
+	mu    sync.Mutex
+	locks map[string]*sync.Mutex
+}
+
+func newOperationCoordinator() *operationCoordinator {
+	return &operationCoordinator{
+		locks: map[string]*sync.Mutex{},
+	}
+}
+
+func (o *operationCoordinator) lock(clusterID string) *sync.Mutex {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	l, ok := o.locks[clusterID]
+	if !ok {
+		l = &sync.Mutex{}
+		o.locks[clusterID] = l
+	}
+
+	return l
+}
+
+// submit runs call for the given cluster, retrying it for as long as the
+// service reports that the cluster is busy with another operation or until ctx
+// is done. Errors which are not conflicts are returned as is.
+func (c *Client) submit(ctx context.Context, clusterID string, call func(context.Context) error) error {
+	backoff := c.opts.MinBackoff
+
+	for {
+		l := clusterOperations.lock(clusterID)
+		_, span := tracer.Start(ctx, "wait for cluster lock", trace.WithAttributes(
+			attribute.String("cloudferro.cluster_id", clusterID),
+		))
+		l.Lock()
+		span.End()
+
+		err := call(ctx)
+		l.Unlock()
+
+		if err == nil || !c.isOperationConflict(ctx, clusterID, err) {
+			return err
+		}
+
+		// jitter spreads retries of runs which collided on the same cluster
+		wait := backoff / 2
+		if wait > 0 {
+			wait += rand.N(wait) //nolint:gosec // jitter does not need a secure source
+		}
+		c.opts.Logger.InfoContext(ctx, "cluster is busy, retrying operation",
+			"cluster_id", clusterID,
+			"retry_in", wait.String(),
+			"error", err.Error(),
+		)
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w: cluster is still busy: %w", ctx.Err(), err)
+		case <-time.After(wait):
+		}
+
+		backoff = min(backoff*2, c.opts.MaxBackoff)
+	}
+}
+
+// isOperationConflict reports whether err was caused by another operation
+// running on the cluster at the same time, which will end by itself. Other
+// failed preconditions, such as a flavor not offered in the region, and
+// clusters being deleted or gone are not retried.
+func (c *Client) isOperationConflict(ctx context.Context, clusterID string, err error) bool {
+	if code := status.Code(err); code != codes.FailedPrecondition && code != codes.Aborted {
+		return false
+	}
+
+	klaster, err := c.clusters.GetCluster(ctx, &clusterservice.GetClusterRequest{
+		ClusterId: clusterID,
+	})
+	if err != nil {
+		return false
+	}
+
+	switch Status(klaster.GetStatus()) {
+	case StatusCreating, StatusUpdating:
+		return true
+	case StatusRunning:
+		// the operation in progress is on a node pool
+		nodePools, err := c.ListNodePools(ctx, clusterID)
+		if err != nil {
+			return false
+		}
+
+		return slices.ContainsFunc(nodePools, func(nodePool *nodepool.NodePool) bool {
+			switch Status(nodePool.GetStatus()) {
+			case StatusCreating, StatusUpdating, StatusDeleting:
+				return true
+			}
+			return false
+		})
+	}
+
+	return false
+}
+
+// poll calls check every poll interval until it reports done, returns an error
+// or ctx is done. pending describes what is still awaited, for the error
+// returned when ctx is done.
+func (c *Client) poll(ctx context.Context, check func() (done bool, pending string, err error)) error {
+	ticker := time.NewTicker(c.opts.PollInterval)
+	defer ticker.Stop()
+
+	// every state waited in gets its own span, so the time spent in each
+	// transition shows in the trace
+	var span trace.Span
+	var spanPending string
+	defer func() {
+		if span != nil {
+			span.End()
+		}
+	}()
+
+	for {
+		done, pending, err := check()
+		if err != nil {
+			if span != nil {
+				span.RecordError(err)
+				span.SetStatus(otelcodes.Error, err.Error())
+			}
+			return err
+		}
+		if done {
+			return nil
+		}
+
+		if span == nil || pending != spanPending {
+			if span != nil {
+				span.End()
+			}
+			_, span = tracer.Start(ctx, "wait for transition", trace.WithAttributes(
+				attribute.String("cloudferro.pending", pending),
+			))
+			spanPending = pending
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w: %s", ctx.Err(), pending)
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package client_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cloudferro/terraform-provider-cloudferro/client"
+	"gitlab.cloudferro.com/k8s/api/nodepoolservice/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestSubmit_retriesWhileNodePoolIsBusy(t *testing.T) {
+	ctx := testContext(t)
+	_, cli, conn := newTestClient(t)
+	klaster := createTestCluster(ctx, t, cli)
+
+	size := int32(1)
+	nodePool, err := cli.CreateNodePool(ctx, klaster.GetId(), client.NodePoolSpec{
+		Name:   "pool",
+		Flavor: "eo2a.large",
+		Size:   &size,
+	}, nil)
+	if err != nil {
+		t.Fatalf("CreateNodePool() error = %v", err)
+	}
+
+	// an update started elsewhere, not waited for
+	busy, err := cli.GetNodePool(ctx, klaster.GetId(), nodePool.GetId())
+	if err != nil {
+		t.Fatalf("GetNodePool() error = %v", err)
+	}
+	if _, err := nodepoolservice.NewNodePoolClient(conn).UpdateNodePool(ctx, &nodepoolservice.UpdateNodePoolRequest{
+		ClusterId:  klaster.GetId(),
+		NodePoolId: nodePool.GetId(),
+		NodePool:   busy,
+	}); err != nil {
+		t.Fatalf("UpdateNodePool() error = %v", err)
+	}
+
+	size = 2
+	updated, err := cli.UpdateNodePool(ctx, klaster.GetId(), nodePool.GetId(), client.NodePoolSpec{Size: &size}, nil)
+	if err != nil {
+		t.Fatalf("UpdateNodePool() error = %v", err)
+	}
+	if updated.GetSize() != 2 {
+		t.Errorf("UpdateNodePool() size = %d, want 2", updated.GetSize())
+	}
+}
+
+func TestSubmit_failedPreconditionOfIdleCluster(t *testing.T) {
+	ctx := testContext(t)
+	srv, cli, _ := newTestClient(t)
+	klaster := createTestCluster(ctx, t, cli)
+
+	srv.InjectError("CreateNodePool", status.Error(codes.FailedPrecondition, "flavor is not available"))
+
+	start := time.Now()
+	size := int32(1)
+	_, err := cli.CreateNodePool(ctx, klaster.GetId(), client.NodePoolSpec{
+		Name:   "pool",
+		Flavor: "eo2a.large",
+		Size:   &size,
+	}, nil)
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("CreateNodePool() error = %v, want FailedPrecondition", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("CreateNodePool() took %s, the error was retried", elapsed)
+	}
+}
@@ -0,0 +1,102 @@
+package cloudferro
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/cloudferro/terraform-provider-cloudferro/client"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// clusterAllowlist limits the clusters the provider may change, and the ones
+// the node pools it may change belong to. An empty allowlist allows all of
+// them.
+type clusterAllowlist struct {
+	ids []string
+	// namePatterns are shell patterns, as matched by path.Match.
+	namePatterns []string
+}
+
+func (a clusterAllowlist) empty() bool {
+	return len(a.ids) == 0 && len(a.namePatterns) == 0
+}
+
+// allowsID reports whether the cluster is allowed by its id alone.
+func (a clusterAllowlist) allowsID(id string) bool {
+	return a.empty() || (id != "" && slices.Contains(a.ids, id))
+}
+
+// allowsName reports whether the cluster is allowed by its name.
+func (a clusterAllowlist) allowsName(name string) bool {
+	for _, el := range a.namePatterns {
+		if ok, _ := path.Match(el, name); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// validateNamePatterns returns the first malformed name pattern.
+func validateNamePatterns(patterns []string) error {
+	for _, el := range patterns {
+		if _, err := path.Match(el, ""); err != nil {
+			return fmt.Errorf("%q: %w", el, err)
+		}
+	}
+
+	return nil
+}
+
+// checkClusterAllowed returns an error when changing the cluster, or one of
+// its node pools, is not allowed. id is null or unknown when the cluster is
+// yet to be created, name is null when it has to be looked up.
+func (s *providerState) checkClusterAllowed(
+	ctx context.Context, cli *client.Client, id, name types.String,
+) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	allow := s.allowlist
+	if allow.allowsID(id.ValueString()) {
+		return diags
+	}
+
+	if name.IsUnknown() || (name.IsNull() && id.IsUnknown()) {
+		// checked once known, when the change is applied
+		return diags
+	}
+
+	if name.IsNull() && len(allow.namePatterns) > 0 {
+		klaster, err := cli.GetCluster(ctx, id.ValueString())
+		if err != nil {
+			diags.Append(apiErrorDiagnostics(ctx, "failed to check the allowed clusters", err)...)
+			return diags
+		}
+		name = types.StringValue(klaster.GetName())
+	}
+
+	if allow.allowsName(name.ValueString()) {
+		return diags
+	}
+
+	var cluster []string
+	if name.ValueString() != "" {
+		cluster = append(cluster, fmt.Sprintf("%q", name.ValueString()))
+	}
+	if id.ValueString() != "" {
+		cluster = append(cluster, fmt.Sprintf("(%s)", id.ValueString()))
+	}
+
+	diags.AddError(
+		"cluster is not allowed",
+		fmt.Sprintf("Cluster %s is neither listed in allowed_cluster_ids nor matches allowed_cluster_name_patterns "+
+			"of the provider, so it cannot be created, changed or deleted, and neither can its node pools.",
+			strings.Join(cluster, " ")),
+	)
+
+	return diags
+}
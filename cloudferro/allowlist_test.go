@@ -0,0 +1,103 @@
+package cloudferro
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloudferro/terraform-provider-cloudferro/client"
+	"github.com/cloudferro/terraform-provider-cloudferro/internal/fakeapi"
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestClusterAllowlist(t *testing.T) {
+	allow := clusterAllowlist{
+		ids:          []string{"4a5e7f8c-1d2b-4c3e-9f0a-6b7c8d9e0f1a"},
+		namePatterns: []string{"team-a-*"},
+	}
+
+	if !allow.allowsID("4a5e7f8c-1d2b-4c3e-9f0a-6b7c8d9e0f1a") || allow.allowsID("") {
+		t.Error("allowsID() does not match the listed ids only")
+	}
+
+	for name, want := range map[string]bool{"team-a-prod": true, "team-b-prod": false, "": false} {
+		if got := allow.allowsName(name); got != want {
+			t.Errorf("allowsName(%q) = %v, want %v", name, got, want)
+		}
+	}
+
+	if !(clusterAllowlist{}).allowsID("") {
+		t.Error("an empty allowlist does not allow every cluster")
+	}
+}
+
+// testAccAllowlistProvider restricts the provider configuration to the
+// cluster with the given id.
+func testAccAllowlistProvider(provider, clusterID string) string {
+	return strings.Replace(provider, "token       =",
+		fmt.Sprintf("allowed_cluster_ids = [%q]\n  token       =", clusterID), 1)
+}
+
+func TestAccProvider_allowedClusterIDs(t *testing.T) {
+	srv, provider := testAccFakeAPI(t, fakeapi.Options{})
+	restricted := testAccAllowlistProvider(provider, "4a5e7f8c-1d2b-4c3e-9f0a-6b7c8d9e0f1a")
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccCheckClusterDestroy(srv),
+		Steps: []resource.TestStep{
+			{
+				Config:      testAccClusterConfig(restricted, "acc-cluster", "1.30.10"),
+				ExpectError: regexp.MustCompile(`cluster is not allowed`),
+			},
+		},
+	})
+}
+
+func TestAccProvider_allowedClusterIDsImported(t *testing.T) {
+	srv, provider := testAccFakeAPI(t, fakeapi.Options{})
+	restricted := testAccAllowlistProvider(provider, "4a5e7f8c-1d2b-4c3e-9f0a-6b7c8d9e0f1a")
+
+	// a cluster managed elsewhere
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Token acc-test-token")
+
+	klaster, err := client.New(srv.ClientConn(), client.Options{PollInterval: 50 * time.Millisecond}).CreateCluster(
+		ctx, client.ClusterSpec{Name: "acc-cluster", Version: "1.30.10", Flavor: "eo2a.large", ControlPlaneSize: 1}, nil,
+	)
+	if err != nil {
+		t.Fatalf("CreateCluster() error = %v", err)
+	}
+
+	config := testAccClusterConfig(restricted, "acc-cluster", "1.30.10")
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccCheckClusterDestroy(srv),
+		Steps: []resource.TestStep{
+			{
+				// importing changes nothing
+				Config: config + fmt.Sprintf(`
+import {
+  to = cloudferro_kubernetes_cluster_v1.test
+  id = %q
+}
+`, klaster.GetId()),
+				Check: resource.TestCheckResourceAttr("cloudferro_kubernetes_cluster_v1.test", "id", klaster.GetId()),
+			},
+			{
+				Config:      restricted,
+				ExpectError: regexp.MustCompile(`cluster is not allowed`),
+			},
+			{
+				// lets the test clean up
+				Config: testAccClusterConfig(provider, "acc-cluster", "1.30.10"),
+			},
+		},
+	})
+}
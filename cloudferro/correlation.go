@@ -0,0 +1,219 @@
+package cloudferro
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// EnvDebugDumpDir is the directory the failed calls are written to, one file
+// per call, for support requests.
+const EnvDebugDumpDir = "CLOUDFERRO_DEBUG_DUMP_DIR"
+
+// correlationKeys are the response metadata identifying a call to the
+// service, in the order they are reported.
+var correlationKeys = []string{requestIDKey, "x-trace-id", "x-correlation-id"}
+
+// traceIDKey holds the id of the OpenTelemetry trace of a call, if traced.
+const traceIDKey = "trace-id"
+
+// callError is an error of the service with what identifies the failed call.
+// It has the status of the error, so it is handled like the error itself.
+type callError struct {
+	err    error
+	method string
+	// ids are the correlation metadata returned by the service, and the
+	// traceIDKey.
+	ids          map[string]string
+	clusterID    string
+	nodePoolID   string
+	dumpFileName string
+}
+
+func (e *callError) Error() string { return e.err.Error() }
+
+func (e *callError) Unwrap() error { return e.err }
+
+// GRPCStatus lets the status package find the status of the error.
+func (e *callError) GRPCStatus() *status.Status { return status.Convert(e.err) }
+
+// detail describes the call for the detail of a diagnostic.
+func (e *callError) detail() string {
+	return e.describe("RPC method")
+}
+
+func (e *callError) describe(methodLabel string) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "%s: %s", methodLabel, e.method)
+	if e.clusterID != "" {
+		fmt.Fprintf(&b, "\nCluster id: %s", e.clusterID)
+	}
+	if e.nodePoolID != "" {
+		fmt.Fprintf(&b, "\nNode pool id: %s", e.nodePoolID)
+	}
+	for _, key := range slices.Concat(correlationKeys, []string{traceIDKey}) {
+		if v := e.ids[key]; v != "" {
+			fmt.Fprintf(&b, "\n%s: %s", key, v)
+		}
+	}
+	if e.dumpFileName != "" {
+		fmt.Fprintf(&b, "\nThe failed call was written to %s.", e.dumpFileName)
+	}
+
+	return b.String()
+}
+
+type lastCallKey struct{}
+
+// lastCall is the latest call of an operation of a resource. It identifies
+// the operation in the diagnostics of errors that are not the error of a
+// call, such as an operation ending in the Error state or a timeout while
+// waiting for it.
+type lastCall struct {
+	mu   sync.Mutex
+	call *callError
+}
+
+// withLastCall returns a context recording the latest call made with it.
+func withLastCall(ctx context.Context) context.Context {
+	return context.WithValue(ctx, lastCallKey{}, &lastCall{})
+}
+
+// record keeps call as the latest call. The cluster and node pool ids of the
+// previous calls are kept when call has none.
+func (l *lastCall) record(call *callError) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.call != nil {
+		if call.clusterID == "" {
+			call.clusterID = l.call.clusterID
+		}
+		if call.nodePoolID == "" {
+			call.nodePoolID = l.call.nodePoolID
+		}
+	}
+	l.call = call
+}
+
+// lastCallDetail describes the latest call recorded in ctx for the detail of
+// a diagnostic, or returns an empty string when there is none.
+func lastCallDetail(ctx context.Context) string {
+	l, ok := ctx.Value(lastCallKey{}).(*lastCall)
+	if !ok {
+		return ""
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.call == nil {
+		return ""
+	}
+
+	return l.call.describe("Last RPC method")
+}
+
+// correlateErrors returns a unary interceptor returning the errors of the
+// service as callError. When dumpDir is set, the failed calls are written
+// there. Every call is recorded as the last call of the context, if any.
+func correlateErrors(dumpDir string) grpc.UnaryClientInterceptor {
+	return func(
+		ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker,
+		opts ...grpc.CallOption,
+	) error {
+		var header, trailer metadata.MD
+		start := time.Now()
+
+		err := invoker(ctx, method, req, reply, cc, append(opts, grpc.Header(&header), grpc.Trailer(&trailer))...)
+		if _, ok := status.FromError(err); !ok {
+			return err
+		}
+
+		callErr := &callError{
+			err:    err,
+			method: method,
+			ids:    map[string]string{},
+		}
+
+		for _, key := range correlationKeys {
+			if v := append(header.Get(key), trailer.Get(key)...); len(v) > 0 {
+				callErr.ids[key] = v[0]
+			}
+		}
+
+		if span := trace.SpanContextFromContext(ctx); span.HasTraceID() {
+			callErr.ids[traceIDKey] = span.TraceID().String()
+		}
+
+		if r, ok := req.(interface{ GetClusterId() string }); ok {
+			callErr.clusterID = r.GetClusterId()
+		}
+		if r, ok := req.(interface{ GetNodePoolId() string }); ok {
+			callErr.nodePoolID = r.GetNodePoolId()
+		}
+
+		if l, ok := ctx.Value(lastCallKey{}).(*lastCall); ok {
+			last := *callErr
+			l.record(&last)
+		}
+
+		if err == nil {
+			return nil
+		}
+
+		if dumpDir != "" {
+			callErr.dumpFileName = dumpCall(dumpDir, callErr, req, header, trailer, time.Since(start))
+		}
+
+		return callErr
+	}
+}
+
+// dumpCall writes the failed call to a new file of dir and returns its name,
+// or an empty name when it could not be written.
+func dumpCall(dir string, callErr *callError, req any, header, trailer metadata.MD, duration time.Duration) string {
+	st := status.Convert(callErr.err)
+
+	data, err := json.MarshalIndent(map[string]any{
+		"method":   callErr.method,
+		"time":     time.Now().UTC().Format(time.RFC3339Nano),
+		"duration": duration.String(),
+		"ids":      callErr.ids,
+		"request":  redactMessage(req),
+		"header":   redactMetadata(header),
+		"trailer":  redactMetadata(trailer),
+		"status":   redactMessage(st.Proto()),
+	}, "", "  ")
+	if err != nil {
+		return ""
+	}
+
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return ""
+	}
+
+	method := callErr.method[strings.LastIndex(callErr.method, "/")+1:]
+	f, err := os.CreateTemp(dir, fmt.Sprintf("%s-%s-*.json", time.Now().UTC().Format("20060102T150405"), method))
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	if _, err := f.Write(data); err != nil {
+		return ""
+	}
+
+	return f.Name()
+}
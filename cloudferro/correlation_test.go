@@ -0,0 +1,104 @@
+package cloudferro
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/cloudferro/terraform-provider-cloudferro/client"
+	"gitlab.cloudferro.com/k8s/api/clusterservice/v1"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestCorrelateErrors(t *testing.T) {
+	dir := t.TempDir()
+
+	invoker := func(_ context.Context, _ string, _, _ any, _ *grpc.ClientConn, opts ...grpc.CallOption) error {
+		for _, el := range opts {
+			if o, ok := el.(grpc.HeaderCallOption); ok {
+				*o.HeaderAddr = metadata.Pairs(requestIDKey, "req-42")
+			}
+		}
+		return status.Error(codes.Internal, "boom")
+	}
+
+	req := &errdetails.ResourceInfo{ResourceName: "c1"}
+	err := correlateErrors(dir)(
+		context.Background(), "/clusterservice.v1.Cluster/GetCluster", req, nil, nil, invoker,
+	)
+
+	if status.Code(err) != codes.Internal || status.Convert(err).Message() != "boom" {
+		t.Fatalf("error = %v, want the status of the service", err)
+	}
+
+	var callErr *callError
+	if !errors.As(err, &callErr) {
+		t.Fatalf("error = %T, want a callError", err)
+	}
+
+	detail := callErr.detail()
+	for _, want := range []string{"GetCluster", "x-request-id: req-42", callErr.dumpFileName} {
+		if !strings.Contains(detail, want) {
+			t.Errorf("detail = %q, want it to contain %q", detail, want)
+		}
+	}
+
+	data, err := os.ReadFile(callErr.dumpFileName)
+	if err != nil {
+		t.Fatalf("failed to read the dump: %v", err)
+	}
+
+	for _, want := range []string{"req-42", `"resource_name": "c1"`} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("dump = %s, want it to contain %s", data, want)
+		}
+	}
+}
+
+func TestLastCallDetail(t *testing.T) {
+	ctx := withLastCall(context.Background())
+
+	invoker := func(requestID string) grpc.UnaryInvoker {
+		return func(_ context.Context, _ string, _, _ any, _ *grpc.ClientConn, opts ...grpc.CallOption) error {
+			for _, el := range opts {
+				if o, ok := el.(grpc.HeaderCallOption); ok {
+					*o.HeaderAddr = metadata.Pairs(requestIDKey, requestID)
+				}
+			}
+			return nil
+		}
+	}
+
+	interceptor := correlateErrors("")
+	if err := interceptor(
+		ctx, "/clusterservice.v1.Cluster/GetCluster", &clusterservice.GetClusterRequest{ClusterId: "c1"}, nil, nil,
+		invoker("req-1"),
+	); err != nil {
+		t.Fatalf("error = %v", err)
+	}
+	if err := interceptor(
+		ctx, "/versionservice.v1.Version/ListVersions", &errdetails.ResourceInfo{}, nil, nil, invoker("req-2"),
+	); err != nil {
+		t.Fatalf("error = %v", err)
+	}
+
+	err := &client.OperationError{Object: "cluster", ID: "c1", Message: "no capacity"}
+	diags := apiErrorDiagnostics(ctx, "failed to create cluster", err)
+	if len(diags) != 1 {
+		t.Fatalf("apiErrorDiagnostics() = %v, want one diagnostic", diags)
+	}
+
+	detail := diags[0].Detail()
+	for _, want := range []string{"no capacity", "Last RPC method: /versionservice.v1.Version/ListVersions",
+		"Cluster id: c1", "x-request-id: req-2"} {
+		if !strings.Contains(detail, want) {
+			t.Errorf("detail = %q, want it to contain %q", detail, want)
+		}
+	}
+}
@@ -0,0 +1,279 @@
+package cloudferro
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	apiconfig "github.com/cloudferro/terraform-provider-cloudferro/internal/config"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	cferror "gitlab.cloudferro.com/k8s/api/error/v1"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// credentialsHint points at the settings of every supported way to
+// authenticate.
+const credentialsHint = "Check the token, token_file, token_command or Keystone settings and the matching " +
+	"environment variables, the token may be invalid or expired."
+
+type apiErrorHint struct {
+	reason string
+	hint   string
+}
+
+var apiErrorHints = map[codes.Code]apiErrorHint{
+	codes.Unauthenticated: {
+		reason: "authentication failed",
+		hint: "The API token was rejected. Set a valid token in the CLOUDFERRO_TOKEN environment variable or " +
+			"the `token` provider attribute, or check the token_file, token_command or Keystone settings, the " +
+			"token may be invalid or expired.",
+	},
+	codes.PermissionDenied: {
+		reason: "permission denied",
+		hint:   "The API token is not allowed to perform this operation in %s.",
+	},
+	codes.NotFound: {
+		reason: "not found",
+		hint:   "The object does not exist, it may have been deleted outside of Terraform.",
+	},
+	codes.AlreadyExists: {
+		reason: "already exists",
+		hint:   "An object with the same name already exists. Choose a different name or import the existing one.",
+	},
+	codes.InvalidArgument: {
+		reason: "invalid request",
+		hint:   "The service rejected the configuration, correct the values listed above.",
+	},
+	codes.FailedPrecondition: {
+		reason: "precondition failed",
+		hint: "The service refused the operation in the current state of the cluster or node pool. If another " +
+			"operation is in progress, wait for it to finish and retry.",
+	},
+	codes.Aborted: {
+		reason: "operation aborted",
+		hint:   "The operation conflicted with another change of the same cluster. Retry once it is finished.",
+	},
+	codes.ResourceExhausted: {
+		reason: "quota exceeded",
+		hint:   "The project quota or the API rate limit was exceeded. Free up resources or request a quota increase.",
+	},
+	codes.Unavailable: {
+		reason: "service unavailable",
+		hint: "The CloudFerro Managed Kubernetes service could not be reached. Check the `region` or `host` " +
+			"provider attributes and the network connectivity, then retry.",
+	},
+	codes.DeadlineExceeded: {
+		reason: "request timed out",
+		hint:   "The service did not respond in time. Retry the operation.",
+	},
+	codes.Unimplemented: {
+		reason: "operation not supported",
+		hint:   "The service does not support this operation.",
+	},
+	codes.Internal: {
+		reason: "internal service error",
+		hint:   "Retry the operation, if the problem persists contact CloudFerro support.",
+	},
+	codes.Unknown: {
+		reason: "unknown service error",
+		hint:   "Retry the operation, if the problem persists contact CloudFerro support.",
+	},
+}
+
+// apiFieldAttributes maps names of API fields reported in field violations to
+// the attribute names used in the schema, where they differ.
+var apiFieldAttributes = map[string]string{
+	"kubernetes_version": "version",
+	"machine_spec":       "flavor",
+}
+
+// apiErrorDiagnostics translates an error returned by the API into diagnostics
+// with a readable summary and a remediation hint. Field violations reported by
+// the service are listed in the general diagnostic. Errors that are not the
+// error of a call are identified by the latest call recorded in ctx.
+func apiErrorDiagnostics(ctx context.Context, summary string, err error) diag.Diagnostics {
+	return errorDiagnostics(ctx, summary, err, func(path.Path) bool { return false })
+}
+
+// resourceErrorDiagnostics is apiErrorDiagnostics for an operation of r. Field
+// violations are attached to the matching attributes of its schema, those that
+// match none are listed in the general diagnostic.
+func resourceErrorDiagnostics(ctx context.Context, r resource.Resource, summary string, err error) diag.Diagnostics {
+	var resp resource.SchemaResponse
+	r.Schema(ctx, resource.SchemaRequest{}, &resp)
+
+	return errorDiagnostics(ctx, summary, err, func(p path.Path) bool {
+		// elements of list attributes are no attributes of their own
+		for last, _ := p.Steps().LastStep(); last != nil; last, _ = p.Steps().LastStep() {
+			if _, ok := last.(path.PathStepElementKeyInt); !ok {
+				break
+			}
+			p = p.ParentPath()
+		}
+
+		_, diags := resp.Schema.AttributeAtPath(ctx, p)
+		return !diags.HasError()
+	})
+}
+
+// errorDiagnostics implements apiErrorDiagnostics, attaching field violations
+// to the attribute paths accepted by attached.
+func errorDiagnostics(ctx context.Context, summary string, err error, attached func(path.Path) bool) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	if errors.Is(err, apiconfig.ErrReadOnly) {
+		diags.AddError(
+			summary+": provider is read-only",
+			err.Error()+"\n\nThe provider is configured with `read_only` or the CLOUDFERRO_READ_ONLY environment "+
+				"variable, which blocks every change. Apply from a runner without it.",
+		)
+		return diags
+	}
+
+	// what identifies the failed call, for support requests
+	var call string
+	if callErr := (*callError)(nil); errors.As(err, &callErr) {
+		call = "\n\n" + callErr.detail()
+	} else if last := lastCallDetail(ctx); last != "" {
+		call = "\n\n" + last
+	}
+
+	st, ok := status.FromError(err)
+	if !ok || st.Code() == codes.OK {
+		diags.AddError(summary, err.Error()+call)
+		return diags
+	}
+
+	hint, ok := apiErrorHints[st.Code()]
+	if !ok {
+		diags.AddError(summary, err.Error()+call)
+		return diags
+	}
+
+	project := "the project the token was issued for"
+	var details []string
+	var violations []*errdetails.BadRequest_FieldViolation
+
+	for _, el := range st.Details() {
+		switch detail := el.(type) {
+		case *cferror.Error:
+			details = append(details, detail.GetMsg())
+		case *errdetails.BadRequest:
+			violations = append(violations, detail.GetFieldViolations()...)
+		case *errdetails.ErrorInfo:
+			if id := detail.GetMetadata()["project_id"]; id != "" {
+				project = fmt.Sprintf("project %s", id)
+			}
+		case *errdetails.ResourceInfo:
+			if detail.GetResourceType() == "project" && detail.GetResourceName() != "" {
+				project = fmt.Sprintf("project %s", detail.GetResourceName())
+			}
+		}
+	}
+
+	summary = fmt.Sprintf("%s: %s", summary, hint.reason)
+
+	// violations that match no attribute are listed in the general diagnostic
+	var unattached []string
+	for _, el := range violations {
+		if p := apiFieldPath(el.GetField()); len(p.Steps()) > 0 && attached(p) {
+			diags.AddAttributeError(p, summary, el.GetDescription()+call)
+		} else {
+			unattached = append(unattached, fmt.Sprintf("%s: %s", el.GetField(), el.GetDescription()))
+		}
+	}
+	if len(violations) > 0 && len(unattached) == 0 {
+		return diags
+	}
+
+	var detail strings.Builder
+	detail.WriteString(st.Message())
+	for _, el := range details {
+		if el != "" && el != st.Message() {
+			detail.WriteString("\n")
+			detail.WriteString(el)
+		}
+	}
+	for _, el := range unattached {
+		detail.WriteString("\n")
+		detail.WriteString(el)
+	}
+	detail.WriteString("\n\n")
+	if st.Code() == codes.PermissionDenied {
+		fmt.Fprintf(&detail, hint.hint, project)
+	} else {
+		detail.WriteString(hint.hint)
+	}
+	detail.WriteString(call)
+
+	diags.AddError(summary, detail.String())
+
+	return diags
+}
+
+// apiFieldPath converts a field path reported by the API, such as
+// "cluster.controlPlane.custom.machineSpec.id" or "nodePool.sharedNetworks[0]",
+// to the attribute path of the schema, "control_plane.flavor" or
+// "shared_networks[0]". The path may name no attribute of the schema.
+func apiFieldPath(field string) path.Path {
+	var p path.Path
+
+	parts := strings.Split(field, ".")
+	for i, el := range parts {
+		index := -1
+		if name, rest, ok := strings.Cut(el, "["); ok {
+			n, err := strconv.Atoi(strings.TrimSuffix(rest, "]"))
+			if err != nil || !strings.HasSuffix(rest, "]") {
+				// not a path the schema can have
+				return path.Root(field)
+			}
+			el, index = name, n
+		}
+
+		el = toSnakeCase(el)
+		if name, ok := apiFieldAttributes[el]; ok {
+			el = name
+		}
+
+		switch {
+		case i == 0 && (el == "cluster" || el == "node_pool" || el == "update"):
+			// request wrappers have no counterpart in the schema
+			continue
+		case el == "custom" || (el == "id" && i > 0):
+			continue
+		}
+
+		if len(p.Steps()) == 0 {
+			p = path.Root(el)
+		} else {
+			p = p.AtName(el)
+		}
+		if index >= 0 {
+			p = p.AtListIndex(index)
+		}
+	}
+
+	return p
+}
+
+func toSnakeCase(s string) string {
+	var b strings.Builder
+	for i, r := range s {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				b.WriteRune('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
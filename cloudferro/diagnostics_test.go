@@ -0,0 +1,117 @@
+package cloudferro
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestAPIErrorDiagnostics(t *testing.T) {
+	violations := func(fields ...string) error {
+		br := &errdetails.BadRequest{}
+		for _, el := range fields {
+			br.FieldViolations = append(br.FieldViolations, &errdetails.BadRequest_FieldViolation{
+				Field:       el,
+				Description: "invalid " + el,
+			})
+		}
+		st, err := status.New(codes.InvalidArgument, "invalid cluster").WithDetails(br)
+		if err != nil {
+			t.Fatalf("WithDetails() error = %v", err)
+		}
+		return st.Err()
+	}
+
+	tests := []struct {
+		name string
+		// the resource of the operation, none if nil
+		resource  resource.Resource
+		err       error
+		wantPaths []path.Path
+		// substrings of the detail of the general diagnostic, none if empty
+		wantDetail []string
+	}{
+		{
+			name:      "attached violations",
+			resource:  &clusterResource{},
+			err:       violations("cluster.name", "cluster.kubernetesVersion"),
+			wantPaths: []path.Path{path.Root("name"), path.Root("version")},
+		},
+		{
+			name:      "list element violation",
+			resource:  &nodePoolResource{},
+			err:       violations("nodePool.sharedNetworks[1]"),
+			wantPaths: []path.Path{path.Root("shared_networks").AtListIndex(1)},
+		},
+		{
+			name:      "unattached violations",
+			resource:  &clusterResource{},
+			err:       violations("cluster.name", "", "cluster.networkId", "sharedNetworks[0]"),
+			wantPaths: []path.Path{path.Root("name"), path.Empty()},
+			wantDetail: []string{
+				"invalid cluster", ": invalid \n", "cluster.networkId: invalid cluster.networkId",
+				"sharedNetworks[0]: invalid sharedNetworks[0]", "correct the values",
+			},
+		},
+		{
+			name:       "violations without a resource",
+			err:        violations("cluster.name"),
+			wantPaths:  []path.Path{path.Empty()},
+			wantDetail: []string{"cluster.name: invalid cluster.name", "correct the values"},
+		},
+		{
+			name:       "no violations",
+			err:        status.Error(codes.InvalidArgument, "invalid cluster"),
+			wantPaths:  []path.Path{path.Empty()},
+			wantDetail: []string{"invalid cluster", "correct the values"},
+		},
+		{
+			name:       "failed precondition",
+			err:        status.Error(codes.FailedPrecondition, "flavor is not available"),
+			wantPaths:  []path.Path{path.Empty()},
+			wantDetail: []string{"flavor is not available", "If another operation is in progress"},
+		},
+		{
+			name:       "unauthenticated",
+			err:        status.Error(codes.Unauthenticated, "token expired"),
+			wantPaths:  []path.Path{path.Empty()},
+			wantDetail: []string{"token expired", "CLOUDFERRO_TOKEN", "token_file, token_command or Keystone settings"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diags := apiErrorDiagnostics(context.Background(), "failed to create cluster", tt.err)
+			if tt.resource != nil {
+				diags = resourceErrorDiagnostics(context.Background(), tt.resource, "failed to create cluster", tt.err)
+			}
+			if len(diags) != len(tt.wantPaths) {
+				t.Fatalf("apiErrorDiagnostics() = %d diagnostics, want %d: %v", len(diags), len(tt.wantPaths), diags)
+			}
+
+			for i, el := range diags {
+				got := path.Empty()
+				if d, ok := el.(diag.DiagnosticWithPath); ok {
+					got = d.Path()
+				}
+				if got.String() != tt.wantPaths[i].String() {
+					t.Errorf("diagnostic %d path = %s, want %s", i, got, tt.wantPaths[i])
+				}
+				if len(got.Steps()) == 0 {
+					for _, want := range tt.wantDetail {
+						if !strings.Contains(el.Detail(), want) {
+							t.Errorf("diagnostic %d detail = %q, want it to contain %q", i, el.Detail(), want)
+						}
+					}
+				}
+			}
+		})
+	}
+}
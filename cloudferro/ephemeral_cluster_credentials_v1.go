@@ -0,0 +1,155 @@
+package cloudferro
+
+import (
+	"context"
+
+	"github.com/cloudferro/terraform-provider-cloudferro/internal/kubeconfig"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral"
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var (
+	_ ephemeral.EphemeralResource              = (*clusterCredentialsEphemeralResource)(nil)
+	_ ephemeral.EphemeralResourceWithConfigure = (*clusterCredentialsEphemeralResource)(nil)
+)
+
+func newClusterCredentialsEphemeralResource() ephemeral.EphemeralResource {
+	return &clusterCredentialsEphemeralResource{}
+}
+
+type clusterCredentialsModel struct {
+	ClusterID            types.String `tfsdk:"cluster_id"`
+	Region               types.String `tfsdk:"region"`
+	Host                 types.String `tfsdk:"host"`
+	ClusterCACertificate types.String `tfsdk:"cluster_ca_certificate"`
+	ClientCertificate    types.String `tfsdk:"client_certificate"`
+	ClientKey            types.String `tfsdk:"client_key"`
+	Token                types.String `tfsdk:"token"`
+	Kubeconfig           types.String `tfsdk:"kubeconfig"`
+}
+
+// clusterCredentialsEphemeralResource reads the admin credentials of a
+// cluster on every run, so that they are never written to the state.
+type clusterCredentialsEphemeralResource struct {
+	provider *providerState
+}
+
+// Configure implements ephemeral.EphemeralResourceWithConfigure.
+func (c *clusterCredentialsEphemeralResource) Configure(
+	ctx context.Context,
+	req ephemeral.ConfigureRequest,
+	resp *ephemeral.ConfigureResponse,
+) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	state, ok := req.ProviderData.(*providerState)
+	if !ok {
+		resp.Diagnostics.AddError("failed to configure ephemeral resource", "invalid provider data type")
+		return
+	}
+	c.provider = state
+}
+
+// Metadata implements ephemeral.EphemeralResource.
+func (c *clusterCredentialsEphemeralResource) Metadata(
+	ctx context.Context, req ephemeral.MetadataRequest, resp *ephemeral.MetadataResponse,
+) {
+	resp.TypeName = req.ProviderTypeName + "_kubernetes_cluster_credentials_v1"
+}
+
+// Open implements ephemeral.EphemeralResource.
+func (c *clusterCredentialsEphemeralResource) Open(
+	ctx context.Context, req ephemeral.OpenRequest, resp *ephemeral.OpenResponse,
+) {
+	ctx, end := startSpan(ctx, "cloudferro_kubernetes_cluster_credentials_v1.Open")
+	defer end(&resp.Diagnostics)
+
+	var data clusterCredentialsModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	cli, diags := regionClient(c.provider, data.Region)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	config, err := cli.Kubeconfig(ctx, data.ClusterID.ValueString())
+	if err != nil {
+		resp.Diagnostics.Append(apiErrorDiagnostics(ctx, "failed to read cluster credentials", err)...)
+		return
+	}
+
+	creds, err := kubeconfig.Parse([]byte(config))
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("cluster_id"), "failed to read cluster credentials", err.Error())
+		return
+	}
+
+	data.Host = types.StringValue(creds.Host)
+	data.ClusterCACertificate = types.StringValue(creds.ClusterCACertificate)
+	data.ClientCertificate = types.StringValue(creds.ClientCertificate)
+	data.ClientKey = types.StringValue(creds.ClientKey)
+	data.Token = types.StringValue(creds.Token)
+	data.Kubeconfig = types.StringValue(config)
+
+	resp.Diagnostics.Append(resp.Result.Set(ctx, data)...)
+}
+
+// Schema implements ephemeral.EphemeralResource.
+func (c *clusterCredentialsEphemeralResource) Schema(
+	ctx context.Context, req ephemeral.SchemaRequest, resp *ephemeral.SchemaResponse,
+) {
+	resp.Schema = schema.Schema{
+		Description: "Admin credentials of a cluster, read from its kubeconfig on every run and never stored " +
+			"in the plan or the state. Requires Terraform 1.10 or later.",
+		Attributes: map[string]schema.Attribute{
+			"cluster_id": schema.StringAttribute{
+				Required:    true,
+				Description: "Id of the cluster.",
+				Validators: []validator.String{
+					stringvalidator.RegexMatches(uuidRegex, "must be valid uuid"),
+				},
+			},
+			"region": schema.StringAttribute{
+				Optional:    true,
+				Description: "Region of the cluster, such as `WAW3-2`. Defaults to the region of the provider.",
+			},
+			"host": schema.StringAttribute{
+				Computed:    true,
+				Description: "Address of the Kubernetes API server.",
+			},
+			"cluster_ca_certificate": schema.StringAttribute{
+				Computed:    true,
+				Description: "PEM-encoded CA certificate of the Kubernetes API server.",
+			},
+			"client_certificate": schema.StringAttribute{
+				Computed:    true,
+				Description: "PEM-encoded client certificate of the admin user.",
+			},
+			"client_key": schema.StringAttribute{
+				Computed:    true,
+				Sensitive:   true,
+				Description: "PEM-encoded client key of the admin user.",
+			},
+			"token": schema.StringAttribute{
+				Computed:    true,
+				Sensitive:   true,
+				Description: "Bearer token of the admin user, empty when the kubeconfig uses a client certificate.",
+			},
+			"kubeconfig": schema.StringAttribute{
+				Computed:    true,
+				Sensitive:   true,
+				Description: "Cluster kubeconfig.",
+			},
+		},
+	}
+}
@@ -0,0 +1,66 @@
+package cloudferro
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/cloudferro/terraform-provider-cloudferro/internal/fakeapi"
+	"github.com/hashicorp/go-version"
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/tfversion"
+)
+
+// testAccClusterCredentialsConfig reads the credentials of the cluster of
+// testAccClusterConfig, checking them with condition as ephemeral values
+// cannot be checked from the state.
+func testAccClusterCredentialsConfig(clusterID, condition string) string {
+	return fmt.Sprintf(`
+ephemeral "cloudferro_kubernetes_cluster_credentials_v1" "test" {
+  cluster_id = %s
+
+  lifecycle {
+    postcondition {
+      condition     = %s
+      error_message = "unexpected cluster credentials"
+    }
+  }
+}
+`, clusterID, condition)
+}
+
+func TestAccKubernetesClusterCredentialsV1(t *testing.T) {
+	srv, provider := testAccFakeAPI(t, fakeapi.Options{})
+	config := strings.Replace(
+		testAccClusterConfig(provider, "acc-cluster", "1.30.10"), "name    =", "store_kubeconfig = false\n  name    =", 1,
+	)
+	clusterID := "cloudferro_kubernetes_cluster_v1.test.id"
+
+	resource.Test(t, resource.TestCase{
+		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
+			// ephemeral resources
+			tfversion.SkipBelow(version.Must(version.NewVersion("1.10.0"))),
+		},
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccCheckClusterDestroy(srv),
+		Steps: []resource.TestStep{
+			{
+				Config: config + testAccClusterCredentialsConfig(clusterID, `startswith(self.host, "https://") && `+
+					`self.cluster_ca_certificate != "" && self.token != "" && strcontains(self.kubeconfig, self.host)`),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckNoResourceAttr("cloudferro_kubernetes_cluster_v1.test", "kubeconfig"),
+				),
+			},
+			{
+				// the condition does see the credentials
+				Config:      config + testAccClusterCredentialsConfig(clusterID, `self.token == ""`),
+				ExpectError: regexp.MustCompile("unexpected cluster credentials"),
+			},
+			{
+				Config:      config + testAccClusterCredentialsConfig(`"00000000-0000-4000-8000-000000000000"`, "true"),
+				ExpectError: regexp.MustCompile("failed to read cluster credentials"),
+			},
+		},
+	})
+}
@@ -0,0 +1,85 @@
+package cloudferro
+
+import (
+	"context"
+
+	"github.com/cloudferro/terraform-provider-cloudferro/internal/kubeconfig"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var _ function.Function = (*kubeconfigDecodeFunction)(nil)
+
+func newKubeconfigDecodeFunction() function.Function {
+	return &kubeconfigDecodeFunction{}
+}
+
+var kubeconfigCredentialsTypes = map[string]attr.Type{
+	"host":                   types.StringType,
+	"cluster_ca_certificate": types.StringType,
+	"client_certificate":     types.StringType,
+	"client_key":             types.StringType,
+	"token":                  types.StringType,
+}
+
+// kubeconfigDecodeFunction returns the address and credentials of a
+// kubeconfig, as read by the cluster credentials ephemeral resource.
+type kubeconfigDecodeFunction struct{}
+
+// Metadata implements function.Function.
+func (f *kubeconfigDecodeFunction) Metadata(
+	ctx context.Context, req function.MetadataRequest, resp *function.MetadataResponse,
+) {
+	resp.Name = "kubeconfig_decode"
+}
+
+// Definition implements function.Function.
+func (f *kubeconfigDecodeFunction) Definition(
+	ctx context.Context, req function.DefinitionRequest, resp *function.DefinitionResponse,
+) {
+	resp.Definition = function.Definition{
+		Summary: "Decode a kubeconfig",
+		Description: "Returns the host, cluster_ca_certificate, client_certificate, client_key and token of the " +
+			"current context of a kubeconfig, such as the kubeconfig of a cluster. The certificates and key are " +
+			"PEM-encoded, the attributes which are not set are empty.",
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:        "kubeconfig",
+				Description: "Kubeconfig to decode.",
+			},
+		},
+		Return: function.ObjectReturn{
+			AttributeTypes: kubeconfigCredentialsTypes,
+		},
+	}
+}
+
+// Run implements function.Function.
+func (f *kubeconfigDecodeFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var config string
+	resp.Error = req.Arguments.Get(ctx, &config)
+	if resp.Error != nil {
+		return
+	}
+
+	creds, err := kubeconfig.Parse([]byte(config))
+	if err != nil {
+		resp.Error = function.NewArgumentFuncError(0, err.Error())
+		return
+	}
+
+	result, diags := types.ObjectValue(kubeconfigCredentialsTypes, map[string]attr.Value{
+		"host":                   types.StringValue(creds.Host),
+		"cluster_ca_certificate": types.StringValue(creds.ClusterCACertificate),
+		"client_certificate":     types.StringValue(creds.ClientCertificate),
+		"client_key":             types.StringValue(creds.ClientKey),
+		"token":                  types.StringValue(creds.Token),
+	})
+	resp.Error = function.FuncErrorFromDiags(ctx, diags)
+	if resp.Error != nil {
+		return
+	}
+
+	resp.Error = resp.Result.Set(ctx, result)
+}
@@ -0,0 +1,59 @@
+package cloudferro
+
+import (
+	"context"
+
+	"github.com/cloudferro/terraform-provider-cloudferro/internal/k8sversion"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+)
+
+var _ function.Function = (*nextMinorFunction)(nil)
+
+func newNextMinorFunction() function.Function {
+	return &nextMinorFunction{}
+}
+
+// nextMinorFunction returns the minor release a cluster can be upgraded to.
+type nextMinorFunction struct{}
+
+// Metadata implements function.Function.
+func (f *nextMinorFunction) Metadata(
+	ctx context.Context, req function.MetadataRequest, resp *function.MetadataResponse,
+) {
+	resp.Name = "next_minor"
+}
+
+// Definition implements function.Function.
+func (f *nextMinorFunction) Definition(
+	ctx context.Context, req function.DefinitionRequest, resp *function.DefinitionResponse,
+) {
+	resp.Definition = function.Definition{
+		Summary: "Return the next minor Kubernetes version",
+		Description: "Returns the first version of the minor release following a Kubernetes version, such as " +
+			"1.31.0 for 1.30.10. The version is not checked against the versions offered by the service.",
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:        "version",
+				Description: "Kubernetes version, such as 1.30.10.",
+			},
+		},
+		Return: function.StringReturn{},
+	}
+}
+
+// Run implements function.Function.
+func (f *nextMinorFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var version string
+	resp.Error = req.Arguments.Get(ctx, &version)
+	if resp.Error != nil {
+		return
+	}
+
+	v, err := k8sversion.Parse(version)
+	if err != nil {
+		resp.Error = function.NewArgumentFuncError(0, err.Error())
+		return
+	}
+
+	resp.Error = resp.Result.Set(ctx, v.NextMinor().String())
+}
@@ -0,0 +1,70 @@
+package cloudferro
+
+import (
+	"context"
+
+	"github.com/cloudferro/terraform-provider-cloudferro/internal/k8sversion"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+)
+
+var _ function.Function = (*versionCompareFunction)(nil)
+
+func newVersionCompareFunction() function.Function {
+	return &versionCompareFunction{}
+}
+
+// versionCompareFunction orders two Kubernetes versions, as accepted by the
+// version of the cluster resource.
+type versionCompareFunction struct{}
+
+// Metadata implements function.Function.
+func (f *versionCompareFunction) Metadata(
+	ctx context.Context, req function.MetadataRequest, resp *function.MetadataResponse,
+) {
+	resp.Name = "version_compare"
+}
+
+// Definition implements function.Function.
+func (f *versionCompareFunction) Definition(
+	ctx context.Context, req function.DefinitionRequest, resp *function.DefinitionResponse,
+) {
+	resp.Definition = function.Definition{
+		Summary: "Compare two Kubernetes versions",
+		Description: "Returns -1, 0 or 1 when the first Kubernetes version, such as 1.30.10, is lower than, " +
+			"equal to or greater than the second one.",
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:        "a",
+				Description: "First version.",
+			},
+			function.StringParameter{
+				Name:        "b",
+				Description: "Second version.",
+			},
+		},
+		Return: function.Int64Return{},
+	}
+}
+
+// Run implements function.Function.
+func (f *versionCompareFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var a, b string
+	resp.Error = req.Arguments.Get(ctx, &a, &b)
+	if resp.Error != nil {
+		return
+	}
+
+	va, err := k8sversion.Parse(a)
+	if err != nil {
+		resp.Error = function.NewArgumentFuncError(0, err.Error())
+		return
+	}
+
+	vb, err := k8sversion.Parse(b)
+	if err != nil {
+		resp.Error = function.NewArgumentFuncError(1, err.Error())
+		return
+	}
+
+	resp.Error = resp.Result.Set(ctx, int64(va.Compare(vb)))
+}
@@ -0,0 +1,45 @@
+package cloudferro
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestVersionCompareFunction(t *testing.T) {
+	tests := []struct {
+		name    string
+		a, b    string
+		want    int64
+		wantErr bool
+	}{
+		{name: "lower", a: "1.30.10", b: "1.31.6", want: -1},
+		{name: "equal", a: "1.31.6", b: "1.31.6", want: 0},
+		{name: "greater patch", a: "1.31.10", b: "1.31.9", want: 1},
+		{name: "invalid", a: "1.31", b: "1.31.6", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := function.RunRequest{
+				Arguments: function.NewArgumentsData([]attr.Value{types.StringValue(tt.a), types.StringValue(tt.b)}),
+			}
+			resp := function.RunResponse{Result: function.NewResultData(types.Int64Unknown())}
+
+			newVersionCompareFunction().Run(context.Background(), req, &resp)
+			if (resp.Error != nil) != tt.wantErr {
+				t.Fatalf("Run() error = %v, wantErr %v", resp.Error, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if got := resp.Result.Value(); !got.Equal(types.Int64Value(tt.want)) {
+				t.Errorf("Run() = %s, want %d", got, tt.want)
+			}
+		})
+	}
+}
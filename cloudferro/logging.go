@@ -0,0 +1,234 @@
+package cloudferro
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// requestIDKey is the response metadata the service returns the id of the
+// request in.
+const requestIDKey = "x-request-id"
+
+// redacted replaces the values of sensitive fields in the logs.
+const redacted = "<redacted>"
+
+// sensitiveLogKeys are the metadata keys and body fields never logged. Log
+// fields with these keys are masked too.
+var sensitiveLogKeys = []string{
+	"authorization",
+	"x-auth-token",
+	"kubeconfig",
+	"token",
+	"password",
+	"secret",
+}
+
+// logCalls is a unary interceptor logging every call to the service: its
+// method, duration, status code and request id at DEBUG, and its redacted
+// metadata, request and response at TRACE.
+func logCalls(
+	ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker,
+	opts ...grpc.CallOption,
+) error {
+	ctx = tflog.MaskFieldValuesWithFieldKeys(ctx, sensitiveLogKeys...)
+
+	md, _ := metadata.FromOutgoingContext(ctx)
+	tflog.Trace(ctx, "calling API", map[string]any{
+		"method":   method,
+		"metadata": redactMetadata(md),
+		"request":  redactMessage(req),
+	})
+
+	var header, trailer metadata.MD
+	start := time.Now()
+	err := invoker(ctx, method, req, reply, cc, append(opts, grpc.Header(&header), grpc.Trailer(&trailer))...)
+
+	fields := map[string]any{
+		"method":   method,
+		"duration": time.Since(start).String(),
+		"code":     status.Code(err).String(),
+	}
+	if id := requestID(header, trailer); id != "" {
+		fields["request_id"] = id
+	}
+	tflog.Debug(ctx, "called API", fields)
+
+	if err == nil {
+		tflog.Trace(ctx, "API response", map[string]any{
+			"method":   method,
+			"response": redactMessage(reply),
+		})
+	}
+
+	return err
+}
+
+// tflogHandler is a slog.Handler writing to the provider logs, for the
+// client. tflog filters the levels itself.
+type tflogHandler struct {
+	attrs []slog.Attr
+	group string
+}
+
+var _ slog.Handler = tflogHandler{}
+
+// Enabled implements slog.Handler.
+func (h tflogHandler) Enabled(context.Context, slog.Level) bool { return true }
+
+// Handle implements slog.Handler.
+func (h tflogHandler) Handle(ctx context.Context, r slog.Record) error {
+	fields := map[string]any{}
+	for _, el := range h.attrs {
+		addLogField(fields, "", el)
+	}
+	r.Attrs(func(el slog.Attr) bool {
+		addLogField(fields, h.group, el)
+		return true
+	})
+
+	switch {
+	case r.Level >= slog.LevelError:
+		tflog.Error(ctx, r.Message, fields)
+	case r.Level >= slog.LevelWarn:
+		tflog.Warn(ctx, r.Message, fields)
+	case r.Level >= slog.LevelInfo:
+		tflog.Info(ctx, r.Message, fields)
+	case r.Level >= slog.LevelDebug:
+		tflog.Debug(ctx, r.Message, fields)
+	default:
+		tflog.Trace(ctx, r.Message, fields)
+	}
+
+	return nil
+}
+
+// WithAttrs implements slog.Handler.
+func (h tflogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	for _, el := range attrs {
+		if h.group != "" {
+			el = slog.Attr{Key: h.group + "." + el.Key, Value: el.Value}
+		}
+		h.attrs = append(slices.Clip(h.attrs), el)
+	}
+
+	return h
+}
+
+// WithGroup implements slog.Handler.
+func (h tflogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+
+	if h.group != "" {
+		name = h.group + "." + name
+	}
+	h.group = name
+
+	return h
+}
+
+// addLogField adds attr to the log fields, the attributes of groups with
+// their keys prefixed by the group.
+func addLogField(fields map[string]any, prefix string, attr slog.Attr) {
+	key := attr.Key
+	if prefix != "" {
+		key = prefix + "." + key
+	}
+
+	value := attr.Value.Resolve()
+	if value.Kind() != slog.KindGroup {
+		fields[key] = value.Any()
+		return
+	}
+
+	for _, el := range value.Group() {
+		addLogField(fields, key, el)
+	}
+}
+
+// requestID returns the id the service gave the request, if any.
+func requestID(header, trailer metadata.MD) string {
+	for _, md := range []metadata.MD{header, trailer} {
+		if v := md.Get(requestIDKey); len(v) > 0 {
+			return v[0]
+		}
+	}
+
+	return ""
+}
+
+func redactMetadata(md metadata.MD) map[string]any {
+	out := map[string]any{}
+	for k, v := range md {
+		if isSensitiveLogKey(k) {
+			out[k] = redacted
+		} else {
+			out[k] = strings.Join(v, ", ")
+		}
+	}
+
+	return out
+}
+
+// redactMessage returns the fields of a request or response with the values
+// of the sensitive ones replaced.
+func redactMessage(msg any) any {
+	m, ok := msg.(proto.Message)
+	if !ok {
+		return nil
+	}
+
+	data, err := protojson.MarshalOptions{UseProtoNames: true}.Marshal(m)
+	if err != nil {
+		return nil
+	}
+
+	var fields any
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil
+	}
+
+	return redactFields(fields)
+}
+
+func redactFields(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, el := range v {
+			if isSensitiveLogKey(k) {
+				v[k] = redacted
+			} else {
+				v[k] = redactFields(el)
+			}
+		}
+	case []any:
+		for i, el := range v {
+			v[i] = redactFields(el)
+		}
+	}
+
+	return v
+}
+
+func isSensitiveLogKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, el := range sensitiveLogKeys {
+		if strings.Contains(key, el) {
+			return true
+		}
+	}
+
+	return false
+}
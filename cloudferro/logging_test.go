@@ -0,0 +1,77 @@
+package cloudferro
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-log/tflogtest"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestRedactFields(t *testing.T) {
+	fields := map[string]any{
+		"cluster_id": "c1",
+		"kubeconfig": "apiVersion: v1",
+		"files": []any{
+			map[string]any{"name": "admin", "client_token": "abc"},
+		},
+	}
+
+	want := map[string]any{
+		"cluster_id": "c1",
+		"kubeconfig": redacted,
+		"files": []any{
+			map[string]any{"name": "admin", "client_token": redacted},
+		},
+	}
+
+	if got := redactFields(fields); !reflect.DeepEqual(got, want) {
+		t.Errorf("redactFields() = %v, want %v", got, want)
+	}
+}
+
+func TestRedactMetadata(t *testing.T) {
+	md := metadata.Pairs("authorization", "Token secret", "x-cloudferro-client", "terraform")
+
+	got := redactMetadata(md)
+	if got["authorization"] != redacted || got["x-cloudferro-client"] != "terraform" {
+		t.Errorf("redactMetadata() = %v", got)
+	}
+}
+
+func TestTflogHandler(t *testing.T) {
+	var out bytes.Buffer
+	ctx := tflogtest.RootLogger(context.Background(), &out)
+
+	logger := slog.New(tflogHandler{}).With("cluster_id", "c1").WithGroup("retry")
+	logger.InfoContext(ctx, "cluster is busy", "in", "1s", slog.Group("error", "code", "Aborted"))
+	logger.DebugContext(ctx, "node pool is still deleting")
+
+	entries, err := tflogtest.MultilineJSONDecode(&out)
+	if err != nil {
+		t.Fatalf("failed to decode the logs: %v", err)
+	}
+
+	want := []map[string]any{
+		{
+			"@level":           "info",
+			"@message":         "cluster is busy",
+			"@module":          "provider",
+			"cluster_id":       "c1",
+			"retry.in":         "1s",
+			"retry.error.code": "Aborted",
+		},
+		{
+			"@level":     "debug",
+			"@message":   "node pool is still deleting",
+			"@module":    "provider",
+			"cluster_id": "c1",
+		},
+	}
+	if !reflect.DeepEqual(entries, want) {
+		t.Errorf("logs = %v, want %v", entries, want)
+	}
+}
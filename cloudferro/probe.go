@@ -0,0 +1,72 @@
+package cloudferro
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/cloudferro/terraform-provider-cloudferro/client"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// probeCredentials makes a cheap authenticated call, so that wrong
+// credentials or an unreachable service are reported once by Configure rather
+// than by every resource. endpoint is the address of the service, for the
+// diagnostics.
+func probeCredentials(ctx context.Context, cli *client.Client, endpoint string) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	ctx, cancel := context.WithTimeout(ctx, credentialsProbeTimeout)
+	defer cancel()
+
+	_, err := cli.ListVersions(ctx, true)
+	if err == nil {
+		return diags
+	}
+
+	const optOut = "\n\nSet skip_credentials_validation to configure the provider without reaching the service."
+
+	st := status.Convert(err)
+	msg := st.Message()
+	// connection failures reach us as the text of an Unavailable status,
+	// other messages can mention certificates or hosts for other reasons
+	unavailable := st.Code() == codes.Unavailable
+
+	switch {
+	case st.Code() == codes.Unauthenticated || st.Code() == codes.PermissionDenied:
+		diags.AddError(
+			"failed to authenticate to the CloudFerro API",
+			fmt.Sprintf("The CloudFerro API at %s rejected the credentials of the provider: %s\n\n",
+				endpoint, msg)+credentialsHint+optOut,
+		)
+	case unavailable && (strings.Contains(msg, "x509:") || strings.Contains(msg, "tls:")):
+		diags.AddError(
+			"failed to verify the CloudFerro API certificate",
+			fmt.Sprintf("The TLS connection to the CloudFerro API at %s failed: %s\n\n"+
+				"Check the server_cert, server_cert_pem and tls_server_name settings, or the certificate "+
+				"presented by a proxy in between.", endpoint, msg)+optOut,
+		)
+	case unavailable && strings.Contains(msg, "no such host"):
+		diags.AddError(
+			"failed to resolve the CloudFerro API host",
+			fmt.Sprintf("The address of the CloudFerro API, %s, could not be resolved: %s\n\n"+
+				"Check the region and host settings and the DNS configuration.", endpoint, msg)+optOut,
+		)
+	case unavailable || st.Code() == codes.DeadlineExceeded:
+		diags.AddError(
+			"failed to reach the CloudFerro API",
+			fmt.Sprintf("The CloudFerro API at %s could not be reached within %s: %s\n\n"+
+				"Check the network connectivity, the proxy_url and transport settings, and that the region "+
+				"is available.", endpoint, credentialsProbeTimeout, msg)+optOut,
+		)
+	default:
+		diags.AddError(
+			"failed to check the CloudFerro API credentials",
+			fmt.Sprintf("The CloudFerro API at %s failed to list the Kubernetes versions: %s", endpoint, err)+optOut,
+		)
+	}
+
+	return diags
+}
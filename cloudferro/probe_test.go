@@ -0,0 +1,78 @@
+package cloudferro
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cloudferro/terraform-provider-cloudferro/client"
+	"github.com/cloudferro/terraform-provider-cloudferro/internal/fakeapi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestProbeCredentials(t *testing.T) {
+	tests := []struct {
+		name string
+		// err fails the probe, none if nil
+		err         error
+		wantSummary string
+	}{
+		{
+			name: "valid",
+		},
+		{
+			name:        "rejected token",
+			err:         status.Error(codes.Unauthenticated, "invalid token"),
+			wantSummary: "failed to authenticate to the CloudFerro API",
+		},
+		{
+			name: "unknown authority",
+			err: status.Error(codes.Unavailable, "connection error: desc = \"transport: authentication handshake "+
+				"failed: tls: failed to verify certificate: x509: certificate signed by unknown authority\""),
+			wantSummary: "failed to verify the CloudFerro API certificate",
+		},
+		{
+			name:        "unknown host",
+			err:         status.Error(codes.Unavailable, "dial tcp: lookup api.example: no such host"),
+			wantSummary: "failed to resolve the CloudFerro API host",
+		},
+		{
+			name:        "connection refused",
+			err:         status.Error(codes.Unavailable, "dial tcp 192.0.2.1:443: connect: connection refused"),
+			wantSummary: "failed to reach the CloudFerro API",
+		},
+		{
+			name:        "server error mentioning a certificate",
+			err:         status.Error(codes.Internal, "failed to renew the cluster certificate"),
+			wantSummary: "failed to check the CloudFerro API credentials",
+		},
+		{
+			name:        "server error mentioning a host",
+			err:         status.Error(codes.FailedPrecondition, "no such host in the cluster: tls: disabled"),
+			wantSummary: "failed to check the CloudFerro API credentials",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := fakeapi.New(fakeapi.Options{})
+			if tt.err != nil {
+				srv.InjectError("List", tt.err)
+			}
+			cli := client.New(srv.ClientConn(), client.Options{PollInterval: time.Millisecond})
+
+			diags := probeCredentials(context.Background(), cli, "api.example:443")
+			if tt.wantSummary == "" {
+				if diags.HasError() {
+					t.Fatalf("probeCredentials() diagnostics = %v", diags)
+				}
+				return
+			}
+
+			if len(diags) != 1 || diags[0].Summary() != tt.wantSummary {
+				t.Errorf("probeCredentials() diagnostics = %v, want %q", diags, tt.wantSummary)
+			}
+		})
+	}
+}
@@ -0,0 +1,175 @@
+package cloudferro
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloudferro/terraform-provider-cloudferro/client"
+	apiconfig "github.com/cloudferro/terraform-provider-cloudferro/internal/config"
+	"github.com/cloudferro/terraform-provider-cloudferro/internal/fakeapi"
+	"github.com/hashicorp/terraform-plugin-framework/providerserver"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
+)
+
+var testAccProtoV6ProviderFactories = map[string]func() (tfprotov6.ProviderServer, error){
+	"cloudferro": providerserver.NewProtocol6WithError(NewProvider("test")()),
+}
+
+func TestMain(m *testing.M) {
+	// the fake API settles within milliseconds
+	pollInterval = 50 * time.Millisecond
+	operationMinBackoff = 50 * time.Millisecond
+
+	os.Exit(m.Run())
+}
+
+// testAccFakeAPI starts the fake CloudFerro API for the duration of the test
+// and returns it along with the provider configuration pointing at it.
+func testAccFakeAPI(t *testing.T, opts fakeapi.Options) (*fakeapi.Server, string) {
+	t.Helper()
+
+	if opts.Token == "" {
+		opts.Token = "acc-test-token"
+	}
+
+	srv, addr, certFile := testAccStartFakeAPI(t, opts)
+
+	return srv, fmt.Sprintf(`
+provider "cloudferro" {
+  host        = %q
+  server_cert = %q
+  token       = %q
+}
+`, addr, certFile, opts.Token)
+}
+
+// testAccStartFakeAPI starts the fake CloudFerro API for the duration of the
+// test and returns it along with its address and certificate file.
+func testAccStartFakeAPI(t *testing.T, opts fakeapi.Options) (*fakeapi.Server, string, string) {
+	t.Helper()
+
+	if opts.TransitionDelay == 0 {
+		opts.TransitionDelay = 200 * time.Millisecond
+	}
+
+	srv := fakeapi.New(opts)
+	addr, certPEM, err := srv.Start("127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to start fake API: %v", err)
+	}
+	t.Cleanup(srv.Stop)
+
+	certFile := filepath.Join(t.TempDir(), "server.pem")
+	if err := os.WriteFile(certFile, certPEM, 0o600); err != nil {
+		t.Fatalf("failed to write server certificate: %v", err)
+	}
+
+	testAccClearEnv(t)
+
+	return srv, addr, certFile
+}
+
+// testAccClearEnv unsets the environment variables read by the provider, so
+// only the test configuration is used.
+func testAccClearEnv(t *testing.T) {
+	t.Helper()
+
+	for _, el := range []string{
+		apiconfig.EnvHost, apiconfig.EnvRegion, apiconfig.EnvToken, apiconfig.EnvCert,
+		apiconfig.EnvTokenFile, apiconfig.EnvTokenCommand, apiconfig.EnvCertPEM, apiconfig.EnvClientCert,
+		apiconfig.EnvClientKey, apiconfig.EnvTLSServerName, apiconfig.EnvInsecure, apiconfig.EnvTransport,
+		apiconfig.EnvReadOnly, EnvDebugDumpDir,
+		apiconfig.EnvAuthURL, apiconfig.EnvApplicationCredentialID, apiconfig.EnvApplicationCredentialSecret,
+		apiconfig.EnvUsername, apiconfig.EnvPassword, apiconfig.EnvUserDomainName,
+		apiconfig.EnvProjectID, apiconfig.EnvProjectName, apiconfig.EnvProjectDomainName,
+	} {
+		t.Setenv(el, "")
+	}
+}
+
+func TestAccProvider_keystoneApplicationCredential(t *testing.T) {
+	keystone := &fakeapi.Keystone{
+		ApplicationCredentialID:     "acc-app-cred",
+		ApplicationCredentialSecret: "acc-app-secret",
+	}
+	keystoneSrv := httptest.NewServer(keystone)
+	t.Cleanup(keystoneSrv.Close)
+
+	srv, addr, certFile := testAccStartFakeAPI(t, fakeapi.Options{VerifyToken: keystone.Valid})
+
+	provider := fmt.Sprintf(`
+provider "cloudferro" {
+  host                          = %q
+  server_cert                   = %q
+  auth_url                      = "%s/v3"
+  application_credential_id     = "acc-app-cred"
+  application_credential_secret = "acc-app-secret"
+}
+`, addr, certFile, keystoneSrv.URL)
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccCheckClusterDestroy(srv),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccClusterConfig(provider, "acc-keystone", "1.30.10"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttrSet("cloudferro_kubernetes_cluster_v1.test", "id"),
+					func(*terraform.State) error {
+						if keystone.Issued() == 0 {
+							return fmt.Errorf("no Keystone token was issued")
+						}
+						return nil
+					},
+				),
+			},
+		},
+	})
+}
+
+func TestAccProvider_invalidCredentials(t *testing.T) {
+	_, provider := testAccFakeAPI(t, fakeapi.Options{})
+	provider = strings.Replace(provider, "acc-test-token", "wrong-token", 1)
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config:      testAccClusterConfig(provider, "acc-cluster", "1.30.10"),
+				ExpectError: regexp.MustCompile(`failed to authenticate to the CloudFerro API`),
+			},
+		},
+	})
+}
+
+func TestProviderState_ClientFor(t *testing.T) {
+	state := &providerState{
+		Client: client.New(nil, client.Options{}),
+		config: apiconfig.Config{Region: "WAW4-1", Token: "token"},
+	}
+
+	for _, region := range []types.String{types.StringNull(), types.StringValue("waw4-1")} {
+		if cli, err := state.ClientFor(region); err != nil || cli != state.Client {
+			t.Errorf("ClientFor(%s) = %p, %v, want the provider client", region, cli, err)
+		}
+	}
+
+	first, err := state.ClientFor(types.StringValue("WAW3-2"))
+	if err != nil {
+		t.Fatalf("ClientFor() error = %v", err)
+	}
+
+	second, _ := state.ClientFor(types.StringValue("waw3-2"))
+	if first == state.Client || first != second {
+		t.Errorf("ClientFor() does not reuse one client for WAW3-2")
+	}
+}
@@ -0,0 +1,119 @@
+package cloudferro
+
+import (
+	"context"
+	"strings"
+
+	"github.com/cloudferro/terraform-provider-cloudferro/client"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// regionAttribute is the region attribute of the resources. Its default and
+// the replacement of the resource depend on the provider region, they are
+// planned by modifyRegionPlan.
+func regionAttribute() schema.StringAttribute {
+	return schema.StringAttribute{
+		Optional: true,
+		Computed: true,
+		Description: "Region the resource is managed in, such as `WAW3-2`. Defaults to the region of the " +
+			"provider. Changing the region the resource is managed in forces a new resource to be created.",
+		PlanModifiers: []planmodifier.String{
+			stringplanmodifier.UseStateForUnknown(),
+		},
+	}
+}
+
+// defaultRegion returns the region of the resources without one, or null when
+// the provider is configured with a host rather than a region.
+func (s *providerState) defaultRegion() types.String {
+	if s.config.Host != "" || s.config.Region == "" {
+		return types.StringNull()
+	}
+
+	return types.StringValue(s.config.Region)
+}
+
+// sameRegion tells whether two values of the region attribute designate the
+// same region, a null one being the provider region.
+func (s *providerState) sameRegion(a, b types.String) bool {
+	if a.IsNull() {
+		a = s.defaultRegion()
+	}
+	if b.IsNull() {
+		b = s.defaultRegion()
+	}
+
+	return strings.EqualFold(a.ValueString(), b.ValueString())
+}
+
+// modifyRegionPlan plans the region of a resource: the provider region when
+// it is not configured nor known from the state. The resource is replaced only
+// when the region it is managed in changes, not when the provider region is
+// written explicitly.
+func (s *providerState) modifyRegionPlan(
+	ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse,
+) {
+	if req.Plan.Raw.IsNull() {
+		return
+	}
+
+	var configured, planned types.String
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("region"), &configured)...)
+	resp.Diagnostics.Append(resp.Plan.GetAttribute(ctx, path.Root("region"), &planned)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if configured.IsNull() && planned.IsUnknown() {
+		planned = s.defaultRegion()
+		resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("region"), planned)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
+	if req.State.Raw.IsNull() {
+		return
+	}
+
+	var current types.String
+	resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root("region"), &current)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// a region known at apply time only may be another one
+	if planned.IsUnknown() || !s.sameRegion(planned, current) {
+		resp.RequiresReplace = append(resp.RequiresReplace, path.Root("region"))
+	}
+}
+
+// regionClient returns the client of the region of a resource.
+func regionClient(state *providerState, region types.String) (*client.Client, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	cli, err := state.ClientFor(region)
+	if err != nil {
+		diags.AddAttributeError(path.Root("region"), "failed to connect to region", err.Error())
+		return nil, diags
+	}
+
+	return cli, diags
+}
+
+// splitImportRegion splits the optional region prefix, "<region>/", off an
+// import id made of n parts.
+func splitImportRegion(id string, n int) (types.String, []string) {
+	parts := strings.Split(id, "/")
+	if len(parts) == n+1 {
+		return types.StringValue(parts[0]), parts[1:]
+	}
+
+	return types.StringNull(), parts
+}
@@ -0,0 +1,116 @@
+package cloudferro
+
+import (
+	"context"
+	"testing"
+
+	apiconfig "github.com/cloudferro/terraform-provider-cloudferro/internal/config"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+func TestProviderState_modifyRegionPlan(t *testing.T) {
+	state := &providerState{config: apiconfig.Config{Region: "WAW3-2"}}
+	regionSchema := schema.Schema{Attributes: map[string]schema.Attribute{"region": regionAttribute()}}
+	objectType := tftypes.Object{AttributeTypes: map[string]tftypes.Type{"region": tftypes.String}}
+
+	// values of the region: nil is null, unknown is tftypes.UnknownValue
+	object := func(region any) tftypes.Value {
+		return tftypes.NewValue(objectType, map[string]tftypes.Value{"region": tftypes.NewValue(tftypes.String, region)})
+	}
+
+	tests := []struct {
+		name string
+		// create has no prior state
+		create              bool
+		state, config, plan any
+		wantPlan            types.String
+		wantReplace         bool
+	}{
+		{
+			name:     "create with the provider region",
+			create:   true,
+			config:   nil,
+			plan:     tftypes.UnknownValue,
+			wantPlan: types.StringValue("WAW3-2"),
+		},
+		{
+			name:     "create in another region",
+			create:   true,
+			config:   "WAW3-1",
+			plan:     "WAW3-1",
+			wantPlan: types.StringValue("WAW3-1"),
+		},
+		{
+			name:     "provider region written explicitly",
+			state:    "WAW3-2",
+			config:   "waw3-2",
+			plan:     "waw3-2",
+			wantPlan: types.StringValue("waw3-2"),
+		},
+		{
+			name:     "imported with a region prefix",
+			state:    "WAW3-2",
+			config:   nil,
+			plan:     "WAW3-2",
+			wantPlan: types.StringValue("WAW3-2"),
+		},
+		{
+			name:     "state without region",
+			state:    nil,
+			config:   nil,
+			plan:     tftypes.UnknownValue,
+			wantPlan: types.StringValue("WAW3-2"),
+		},
+		{
+			name:        "other region",
+			state:       "WAW3-2",
+			config:      "WAW3-1",
+			plan:        "WAW3-1",
+			wantPlan:    types.StringValue("WAW3-1"),
+			wantReplace: true,
+		},
+		{
+			name:        "unknown region",
+			state:       "WAW3-2",
+			config:      tftypes.UnknownValue,
+			plan:        tftypes.UnknownValue,
+			wantPlan:    types.StringUnknown(),
+			wantReplace: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := resource.ModifyPlanRequest{
+				Config: tfsdk.Config{Schema: regionSchema, Raw: object(tt.config)},
+				Plan:   tfsdk.Plan{Schema: regionSchema, Raw: object(tt.plan)},
+				State:  tfsdk.State{Schema: regionSchema, Raw: tftypes.NewValue(objectType, nil)},
+			}
+			if !tt.create {
+				req.State.Raw = object(tt.state)
+			}
+			resp := resource.ModifyPlanResponse{Plan: req.Plan}
+
+			state.modifyRegionPlan(context.Background(), req, &resp)
+			if resp.Diagnostics.HasError() {
+				t.Fatalf("modifyRegionPlan() diagnostics = %v", resp.Diagnostics)
+			}
+
+			var got types.String
+			if diags := resp.Plan.GetAttribute(context.Background(), path.Root("region"), &got); diags.HasError() {
+				t.Fatalf("GetAttribute() diagnostics = %v", diags)
+			}
+			if !got.Equal(tt.wantPlan) {
+				t.Errorf("modifyRegionPlan() region = %s, want %s", got, tt.wantPlan)
+			}
+			if replace := len(resp.RequiresReplace) > 0; replace != tt.wantReplace {
+				t.Errorf("modifyRegionPlan() requires replace = %t, want %t", replace, tt.wantReplace)
+			}
+		})
+	}
+}
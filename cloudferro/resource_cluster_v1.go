@@ -2,53 +2,33 @@ package cloudferro
 
 import (
 	"context"
+	"errors"
 	"regexp"
-	"sync"
-	"time"
 
-	"github.com/cloudferro/terraform-provider-cloudferro/internal/utils"
-	"github.com/google/uuid"
+	"github.com/cloudferro/terraform-provider-cloudferro/client"
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
 	"github.com/hashicorp/terraform-plugin-framework-validators/int32validator"
 	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
 	"github.com/hashicorp/terraform-plugin-framework/attr"
 	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/objectplanmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
 	"github.com/hashicorp/terraform-plugin-framework/types"
-	"github.com/hashicorp/terraform-plugin-log/tflog"
-	"gitlab.cloudferro.com/k8s/api/clusterservice/v1"
-	"gitlab.cloudferro.com/k8s/api/kubernetesversion/v1"
-	"gitlab.cloudferro.com/k8s/api/kubernetesversionservice/v1"
-	"gitlab.cloudferro.com/k8s/api/machinespec/v1"
-	"gitlab.cloudferro.com/k8s/api/machinespecservice/v1"
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/status"
+	"gitlab.cloudferro.com/k8s/api/cluster/v1"
 )
 
-var (
-	resourceMutexes sync.Map
-	globalMutex     sync.Mutex
-)
-
-func getResourceMutex(resourceID string) *sync.Mutex {
-	globalMutex.Lock()
-	defer globalMutex.Unlock()
-
-	actual, _ := resourceMutexes.LoadOrStore(resourceID, &sync.Mutex{})
-	return actual.(*sync.Mutex)
-}
-
 var uuidRegex = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-[1-5][0-9a-f]{3}-[89abAB][0-9a-f]{3}-[0-9a-f]{12}$`)
 
 var (
 	_ resource.Resource                = (*clusterResource)(nil)
 	_ resource.ResourceWithConfigure   = (*clusterResource)(nil)
 	_ resource.ResourceWithImportState = (*clusterResource)(nil)
+	_ resource.ResourceWithModifyPlan  = (*clusterResource)(nil)
 )
 
 func newClusterResource() resource.Resource {
@@ -67,12 +47,17 @@ type clusterModel struct {
 	Version      types.String             `tfsdk:"version"`
 	ControlPlane clusterModelControlPlane `tfsdk:"control_plane"`
 	Kubeconfig   types.String             `tfsdk:"kubeconfig"`
-	Metadata     types.Object             `tfsdk:"metadata"`
-	RouterIP     types.String             `tfsdk:"router_ip"`
+	// StoreKubeconfig is null in the states written before it was added,
+	// which stored the kubeconfig.
+	StoreKubeconfig types.Bool     `tfsdk:"store_kubeconfig"`
+	Metadata        types.Object   `tfsdk:"metadata"`
+	RouterIP        types.String   `tfsdk:"router_ip"`
+	Region          types.String   `tfsdk:"region"`
+	Timeouts        timeouts.Value `tfsdk:"timeouts"`
 }
 
 type clusterResource struct {
-	cli *grpc.ClientConn
+	provider *providerState
 }
 
 // ImportState implements resource.ResourceWithImportState.
@@ -81,20 +66,43 @@ func (c *clusterResource) ImportState(
 	req resource.ImportStateRequest,
 	resp *resource.ImportStateResponse,
 ) {
+	ctx, end := startSpan(ctx, "cloudferro_kubernetes_cluster_v1.ImportState")
+	defer end(&resp.Diagnostics)
+
 	var state clusterModel
 
-	if req.ID == "" {
-		resp.Diagnostics.AddError("failed to import state", "cluster_id is not known")
+	region, parts := splitImportRegion(req.ID, 1)
+	if len(parts) != 1 || parts[0] == "" {
+		resp.Diagnostics.AddError(
+			"failed to import state",
+			"id must be in the format of <cluster_id>, <cluster_name> or <region>/<cluster_id or name>",
+		)
 		return
 	}
 
-	if _, err := uuid.Parse(req.ID); err != nil {
-		resp.Diagnostics.AddError("failed to import state", "cluster_id must be valid uuid")
+	cli, diags := regionClient(c.provider, region)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
 	}
 
-	state.ID = types.StringValue(req.ID)
+	clusterID, err := cli.ResolveClusterID(ctx, parts[0])
+	if err != nil {
+		resp.Diagnostics.Append(resourceErrorDiagnostics(ctx, c, "failed to import state", err)...)
+		return
+	}
+
+	state.ID = types.StringValue(clusterID)
+	state.Region = region
+	if region.IsNull() {
+		state.Region = c.provider.defaultRegion()
+	}
+	// the configuration is not known on import, keep the kubeconfig out of
+	// the state until the next apply stores it if asked to
+	state.StoreKubeconfig = types.BoolValue(false)
+	state.Timeouts = nullTimeouts(ctx)
 
-	resp.Diagnostics.Append(c.refreshClusterState(ctx, &state)...)
+	resp.Diagnostics.Append(refreshClusterState(ctx, cli, &state)...)
 	if resp.Diagnostics.HasError() {
 		return
 	}
@@ -120,24 +128,54 @@ func (c *clusterResource) Configure(
 		resp.Diagnostics.AddError("failed to configure resource", "invalid provider data type")
 		return
 	}
-	c.cli = state.Cli
+	c.provider = state
 }
 
-func (c *clusterResource) refreshClusterState(ctx context.Context, state *clusterModel) diag.Diagnostics {
+func refreshClusterState(ctx context.Context, cli *client.Client, state *clusterModel) diag.Diagnostics {
 	clusterID := state.ID.ValueString()
 	var diags diag.Diagnostics
 
-	clusterCli := clusterservice.NewClusterClient(c.cli)
-
-	klaster, err := clusterCli.GetCluster(ctx, &clusterservice.GetClusterRequest{
-		ClusterId:   clusterID,
-		ExtraFields: "errors",
-	})
+	klaster, err := cli.GetCluster(ctx, clusterID)
 	if err != nil {
-		diags.AddError("failed to refresh cluster state", err.Error())
+		diags.Append(apiErrorDiagnostics(ctx, "failed to refresh cluster state", err)...)
+		return diags
+	}
+
+	if state.StoreKubeconfig.IsNull() || state.StoreKubeconfig.IsUnknown() {
+		state.StoreKubeconfig = types.BoolValue(true)
+	}
+
+	if !state.StoreKubeconfig.ValueBool() {
+		state.Kubeconfig = types.StringNull()
+	} else if client.Status(klaster.GetStatus()) == client.StatusRunning {
+		kubeconfig, err := cli.Kubeconfig(ctx, clusterID)
+		if err != nil {
+			diags.Append(apiErrorDiagnostics(ctx, "failed to refresh cluster state", err)...)
+			return diags
+		}
+
+		state.Kubeconfig = types.StringValue(kubeconfig)
+	}
+
+	diags.Append(setClusterState(klaster, state)...)
+	if diags.HasError() {
 		return diags
 	}
 
+	if client.Status(klaster.GetStatus()) == client.StatusError {
+		if lastErr := client.LatestError(klaster); lastErr != nil {
+			diags.Append(apiErrorDiagnostics(ctx, "failed to refresh cluster state", errors.New(lastErr.GetMsg()))...)
+		}
+	}
+
+	return diags
+}
+
+// setClusterState copies the cluster into the state. The kubeconfig is not
+// part of the cluster, it is kept as is or set to null when unknown.
+func setClusterState(klaster *cluster.Cluster, state *clusterModel) diag.Diagnostics {
+	var diags diag.Diagnostics
+
 	state.ID = types.StringValue(klaster.GetId())
 	state.Name = types.StringValue(klaster.GetName())
 	state.Status = types.StringValue(klaster.GetStatus())
@@ -150,17 +188,7 @@ func (c *clusterResource) refreshClusterState(ctx context.Context, state *cluste
 		state.RouterIP = types.StringNull()
 	}
 
-	if klaster.GetStatus() == "Running" {
-		files, err := clusterCli.GetClusterFiles(ctx, &clusterservice.GetClusterFilesRequest{
-			ClusterId: clusterID,
-		})
-		if err != nil {
-			diags.AddError("failed to refresh cluster state", err.Error())
-			return diags
-		}
-
-		state.Kubeconfig = types.StringValue(files.GetKubeconfig())
-	} else if state.Kubeconfig.IsUnknown() {
+	if state.Kubeconfig.IsUnknown() {
 		state.Kubeconfig = types.StringNull()
 	}
 
@@ -186,99 +214,56 @@ func (c *clusterResource) refreshClusterState(ctx context.Context, state *cluste
 		)
 	}
 
-	if klaster.Status == "Error" && len(klaster.Errors) > 0 {
-		lastErr, err := utils.GetLatestClusterError(ctx, c.cli, clusterID)
-		if err != nil {
-			diags.AddError("failed to refresh cluster state", err.Error())
-			return diags
-		}
-
-		diags.AddError("failed to refresh cluster state", lastErr.Msg)
-
-	}
-
 	return diags
 }
 
 // Create implements resource.Resource.
 func (c *clusterResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	ctx, end := startSpan(ctx, "cloudferro_kubernetes_cluster_v1.Create")
+	defer end(&resp.Diagnostics)
+
 	var state clusterModel
 	resp.Diagnostics.Append(req.Plan.Get(ctx, &state)...)
 	if resp.Diagnostics.HasError() {
 		return
 	}
 
-	clusterCli := clusterservice.NewClusterClient(c.cli)
-	versionCli := kubernetesversionservice.NewKubernetesVersionClient(c.cli)
-	machineCli := machinespecservice.NewMachineSpecClient(c.cli)
-
-	var machineSpec *machinespec.MachineSpec
-	machines, err := machineCli.List(ctx, &machinespecservice.ListRequest{
-		Name: state.ControlPlane.Flavor.ValueStringPointer(),
-	})
-	if err != nil {
-		resp.Diagnostics.AddError("failed to create cluster", err.Error())
-		return
-	}
-
-	for _, el := range machines.GetItems() {
-		if el.GetName() == state.ControlPlane.Flavor.ValueString() {
-			machineSpec = el
-			break
-		}
-	}
-	if machineSpec == nil {
-		resp.Diagnostics.AddError("failed to create cluster", "control plane flavor not found")
+	ctx, cancel, diags := withTimeout(ctx, state.Timeouts.Create)
+	defer cancel()
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
 		return
 	}
 
-	var version *kubernetesversion.KubernetesVersion
-	versions, err := versionCli.List(ctx, &kubernetesversionservice.ListRequest{
-		Version: state.Version.ValueStringPointer(),
-	})
-	if err != nil {
-		resp.Diagnostics.AddError("failed to create cluster", err.Error())
+	cli, diags := regionClient(c.provider, state.Region)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
 		return
 	}
 
-	for _, el := range versions.GetItems() {
-		if el.GetVersion() == state.Version.ValueString() {
-			version = el
-			break
-		}
-	}
-
-	if version == nil {
-		resp.Diagnostics.AddError("failed to create cluster", "version not found")
+	// the name may have been unknown when the plan was checked
+	resp.Diagnostics.Append(c.provider.checkClusterAllowed(ctx, cli, types.StringNull(), state.Name)...)
+	if resp.Diagnostics.HasError() {
 		return
 	}
 
-	result, err := clusterCli.CreateCluster(ctx, &clusterservice.CreateClusterRequest{
-		Cluster: &clusterservice.CreateCluster{
-			Name: state.Name.ValueString(),
-			KubernetesVersion: &clusterservice.CreateCluster_KubernetesVersion{
-				Id: version.Id,
-			},
-			ControlPlane: &clusterservice.CreateCluster_ControlPlane{
-				Value: &clusterservice.CreateCluster_ControlPlane_Custom{
-					Custom: &clusterservice.CreateCluster_ControlPlaneCustom{
-						Size: state.ControlPlane.Size.ValueInt32(),
-						MachineSpec: &clusterservice.CreateCluster_MachineSpec{
-							Id: machineSpec.Id,
-						},
-					},
-				},
-			},
-		},
+	_, err := cli.CreateCluster(ctx, client.ClusterSpec{
+		Name:             state.Name.ValueString(),
+		Version:          state.Version.ValueString(),
+		Flavor:           state.ControlPlane.Flavor.ValueString(),
+		ControlPlaneSize: state.ControlPlane.Size.ValueInt32(),
+	}, func(klaster *cluster.Cluster) {
+		// keep the state up to date, so the cluster is tracked even if
+		// creation fails or is interrupted
+		resp.Diagnostics.Append(setClusterState(klaster, &state)...)
+		resp.Diagnostics.Append(resp.State.Set(ctx, state)...)
 	})
 	if err != nil {
-		resp.Diagnostics.AddError("failed to create cluster", err.Error())
+		resp.Diagnostics.Append(resourceErrorDiagnostics(ctx, c, "failed to create cluster", err)...)
 		return
 	}
 
-	// update current state with id's
-	state.ID = types.StringValue(result.Id)
-	resp.Diagnostics.Append(c.refreshClusterState(ctx, &state)...)
+	resp.Diagnostics.Append(refreshClusterState(ctx, cli, &state)...)
 	if resp.Diagnostics.HasError() {
 		return
 	}
@@ -287,38 +272,13 @@ func (c *clusterResource) Create(ctx context.Context, req resource.CreateRequest
 	if resp.Diagnostics.HasError() {
 		return
 	}
-
-	ticker := time.NewTicker(10 * time.Second)
-	defer ticker.Stop()
-
-	for {
-		resp.Diagnostics.Append(c.refreshClusterState(ctx, &state)...)
-		if resp.Diagnostics.HasError() {
-			return
-		}
-
-		resp.Diagnostics.Append(resp.State.Set(ctx, state)...)
-		if resp.Diagnostics.HasError() {
-			return
-		}
-
-		if state.Status.ValueString() == "Running" {
-			return
-		}
-		select {
-		case <-ctx.Done():
-			resp.Diagnostics.AddError("failed to create cluster", ctx.Err().Error())
-			return
-
-		case <-ticker.C:
-			continue
-
-		}
-	}
 }
 
 // Delete implements resource.Resource.
 func (c *clusterResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	ctx, end := startSpan(ctx, "cloudferro_kubernetes_cluster_v1.Delete")
+	defer end(&resp.Diagnostics)
+
 	var state clusterModel
 	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
 	if resp.Diagnostics.HasError() {
@@ -330,67 +290,69 @@ func (c *clusterResource) Delete(ctx context.Context, req resource.DeleteRequest
 		return
 	}
 
-	clusterID := state.ID.ValueString()
+	ctx, cancel, diags := withTimeout(ctx, state.Timeouts.Delete)
+	defer cancel()
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
 
-	cli := clusterservice.NewClusterClient(c.cli)
+	cli, diags := regionClient(c.provider, state.Region)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
 
-	var err error
-	_, err = cli.DeleteCluster(ctx, &clusterservice.DeleteClusterRequest{
-		ClusterId: state.ID.ValueString(),
-	})
+	err := cli.DeleteCluster(ctx, state.ID.ValueString())
 	if err != nil {
-		resp.Diagnostics.AddError("failed to delete cluster", err.Error())
+		resp.Diagnostics.Append(resourceErrorDiagnostics(ctx, c, "failed to delete cluster", err)...)
 		return
 	}
+}
 
-	ticker := time.NewTicker(10 * time.Second)
-	defer ticker.Stop()
+// Metadata implements resource.Resource.
+func (c *clusterResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_kubernetes_cluster_v1"
+}
 
-	for {
-		cl, err := cli.GetCluster(ctx, &clusterservice.GetClusterRequest{
-			ClusterId: clusterID,
-		})
-		if st, ok := status.FromError(err); ok && st.Code() == codes.NotFound {
-			return
-		} else if err != nil {
-			resp.Diagnostics.AddError("failed to delete cluster", err.Error())
-			return
-		}
+// ModifyPlan implements resource.ResourceWithModifyPlan. Changes of clusters
+// the provider is not allowed to change fail at plan time.
+func (c *clusterResource) ModifyPlan(
+	ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse,
+) {
+	if c.provider == nil {
+		return
+	}
 
-		if cl.Status == "Error" {
-			lastErr, err := utils.GetLatestClusterError(ctx, c.cli, clusterID)
-			if err != nil {
-				resp.Diagnostics.AddError("failed to delete cluster", err.Error())
-				return
-			}
-
-			if lastErr != nil {
-				resp.Diagnostics.AddError("failed to delete cluster", lastErr.Msg)
-			} else {
-				resp.Diagnostics.AddError(
-					"failed to delete cluster",
-					"cluster in the invalid state",
-				)
-			}
-			return
-		}
+	c.provider.modifyRegionPlan(ctx, req, resp)
+	if resp.Diagnostics.HasError() || c.provider.allowlist.empty() || resp.Plan.Raw.Equal(req.State.Raw) {
+		return
+	}
 
-		select {
-		case <-ctx.Done():
-			return
-		case <-ticker.C:
-			continue
-		}
+	var cluster clusterModel
+	if req.State.Raw.IsNull() {
+		resp.Diagnostics.Append(resp.Plan.Get(ctx, &cluster)...)
+	} else {
+		resp.Diagnostics.Append(req.State.Get(ctx, &cluster)...)
+	}
+	if resp.Diagnostics.HasError() {
+		return
 	}
-}
 
-// Metadata implements resource.Resource.
-func (c *clusterResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
-	resp.TypeName = req.ProviderTypeName + "_kubernetes_cluster_v1"
+	cli, diags := regionClient(c.provider, cluster.Region)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(c.provider.checkClusterAllowed(ctx, cli, cluster.ID, cluster.Name)...)
 }
 
 // Read implements resource.Resource.
 func (c *clusterResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	ctx, end := startSpan(ctx, "cloudferro_kubernetes_cluster_v1.Read")
+	defer end(&resp.Diagnostics)
+
 	var state clusterModel
 	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
 	if resp.Diagnostics.HasError() {
@@ -402,7 +364,18 @@ func (c *clusterResource) Read(ctx context.Context, req resource.ReadRequest, re
 		return
 	}
 
-	resp.Diagnostics.Append(c.refreshClusterState(ctx, &state)...)
+	// resources created before the region was computed have none
+	if state.Region.IsNull() {
+		state.Region = c.provider.defaultRegion()
+	}
+
+	cli, diags := regionClient(c.provider, state.Region)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(refreshClusterState(ctx, cli, &state)...)
 	if resp.Diagnostics.HasError() {
 		return
 	}
@@ -427,7 +400,7 @@ func (c *clusterResource) Schema(ctx context.Context, req resource.SchemaRequest
 				Required:    true,
 				Description: "Kubernetes version.",
 				Validators: []validator.String{
-					stringvalidator.RegexMatches(regexp.MustCompile(`\d+\.\d+\.\d+`), "must be a valid version"),
+					kubernetesVersionValidator{},
 				},
 			},
 			"name": schema.StringAttribute{
@@ -464,9 +437,19 @@ func (c *clusterResource) Schema(ctx context.Context, req resource.SchemaRequest
 				},
 			},
 			"kubeconfig": schema.StringAttribute{
-				Computed:    true,
-				Sensitive:   true,
-				Description: "Cluster kubeconfig. Should be used with kubectl to interact with the cluster.",
+				Computed:  true,
+				Sensitive: true,
+				Description: "Cluster kubeconfig. Should be used with kubectl to interact with the cluster. " +
+					"Null when `store_kubeconfig` is false.",
+			},
+			"store_kubeconfig": schema.BoolAttribute{
+				Optional: true,
+				Computed: true,
+				Default:  booldefault.StaticBool(true),
+				Description: "Whether to store the admin kubeconfig of the cluster in `kubeconfig`, and so in " +
+					"the state. Set it to false and use the `cloudferro_kubernetes_cluster_credentials_v1` " +
+					"ephemeral resource to keep the credentials out of the state. Defaults to true. Imported " +
+					"clusters start without the kubeconfig, the next apply stores it unless this is false.",
 			},
 			"metadata": schema.SingleNestedAttribute{
 				Computed:    true,
@@ -482,12 +465,17 @@ func (c *clusterResource) Schema(ctx context.Context, req resource.SchemaRequest
 				Computed:    true,
 				Description: "Address of the cluster gateway.",
 			},
+			"region":   regionAttribute(),
+			"timeouts": timeoutsAttribute(ctx),
 		},
 	}
 }
 
 // Update implements resource.Resource.
 func (c *clusterResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	ctx, end := startSpan(ctx, "cloudferro_kubernetes_cluster_v1.Update")
+	defer end(&resp.Diagnostics)
+
 	var current clusterModel
 	resp.Diagnostics.Append(req.State.Get(ctx, &current)...)
 	if resp.Diagnostics.HasError() {
@@ -500,71 +488,45 @@ func (c *clusterResource) Update(ctx context.Context, req resource.UpdateRequest
 		return
 	}
 
-	clusterID := current.ID.ValueString()
-
-	cli := clusterservice.NewClusterClient(c.cli)
-	versionCli := kubernetesversionservice.NewKubernetesVersionClient(c.cli)
-
-	klaster, err := cli.GetCluster(ctx, &clusterservice.GetClusterRequest{ClusterId: clusterID})
-	if err != nil {
-		resp.Diagnostics.AddError("failed to update cluster", err.Error())
+	ctx, cancel, diags := withTimeout(ctx, request.Timeouts.Update)
+	defer cancel()
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
 		return
 	}
 
-	xTrue := true
-	versions, err := versionCli.List(ctx, &kubernetesversionservice.ListRequest{
-		Version:  request.Version.ValueStringPointer(),
-		IsActive: &xTrue,
-	})
-	if err != nil {
-		resp.Diagnostics.AddError("failed to update cluster", err.Error())
+	cli, diags := regionClient(c.provider, current.Region)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
 		return
 	}
 
-	if len(versions.GetItems()) != 1 {
-		resp.Diagnostics.AddError(
-			"failed to update cluster",
-			"failed to get kubernetes version",
+	// the same region, possibly spelled otherwise
+	current.Region = request.Region
+	current.Timeouts = request.Timeouts
+
+	if !request.Version.Equal(current.Version) {
+		_, err := cli.UpgradeCluster(ctx, current.ID.ValueString(), request.Version.ValueString(),
+			func(klaster *cluster.Cluster) {
+				resp.Diagnostics.Append(setClusterState(klaster, &current)...)
+				resp.Diagnostics.Append(resp.State.Set(ctx, &current)...)
+			},
 		)
-		return
+		if err != nil {
+			resp.Diagnostics.Append(resourceErrorDiagnostics(ctx, c, "failed to update cluster", err)...)
+			return
+		}
 	}
 
-	klaster.Version.Id = versions.GetItems()[0].GetId()
+	current.StoreKubeconfig = request.StoreKubeconfig
 
-	tflog.Info(ctx, "update cluser", map[string]any{"object": klaster})
-	_, err = cli.UpdateCluster(ctx, &clusterservice.UpdateClusterRequest{
-		ClusterId: clusterID,
-		Update:    klaster,
-	})
-	if err != nil {
-		resp.Diagnostics.AddError("failde to update cluster", err.Error())
+	resp.Diagnostics.Append(refreshClusterState(ctx, cli, &current)...)
+	if resp.Diagnostics.HasError() {
 		return
 	}
 
-	ticker := time.NewTicker(10 * time.Second)
-	defer ticker.Stop()
-
-	for {
-		resp.Diagnostics.Append(c.refreshClusterState(ctx, &current)...)
-		if resp.Diagnostics.HasError() {
-			return
-		}
-
-		resp.Diagnostics.Append(resp.State.Set(ctx, &current)...)
-		if resp.Diagnostics.HasError() {
-			return
-		}
-
-		if current.Status.ValueString() == "Running" {
-			break
-		}
-
-		select {
-		case <-ctx.Done():
-			resp.Diagnostics.AddError("failed to update cluster", ctx.Err().Error())
-			return
-		case <-ticker.C:
-			continue
-		}
+	resp.Diagnostics.Append(resp.State.Set(ctx, &current)...)
+	if resp.Diagnostics.HasError() {
+		return
 	}
 }
@@ -0,0 +1,174 @@
+package cloudferro
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/cloudferro/terraform-provider-cloudferro/internal/fakeapi"
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func testAccClusterConfig(provider, name, version string) string {
+	return provider + fmt.Sprintf(`
+resource "cloudferro_kubernetes_cluster_v1" "test" {
+  name    = %q
+  version = %q
+  control_plane = {
+    flavor = "eo2a.large"
+    size   = 1
+  }
+}
+`, name, version)
+}
+
+func testAccCheckClusterDestroy(srv *fakeapi.Server) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		for _, rs := range s.RootModule().Resources {
+			if rs.Type != "cloudferro_kubernetes_cluster_v1" {
+				continue
+			}
+
+			if srv.ClusterExists(rs.Primary.ID) {
+				return fmt.Errorf("cluster %s still exists", rs.Primary.ID)
+			}
+		}
+
+		return nil
+	}
+}
+
+func TestAccKubernetesClusterV1_basic(t *testing.T) {
+	srv, provider := testAccFakeAPI(t, fakeapi.Options{})
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccCheckClusterDestroy(srv),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccClusterConfig(provider, "acc-cluster", "1.30.10"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttrSet("cloudferro_kubernetes_cluster_v1.test", "id"),
+					resource.TestCheckResourceAttr("cloudferro_kubernetes_cluster_v1.test", "name", "acc-cluster"),
+					resource.TestCheckResourceAttr("cloudferro_kubernetes_cluster_v1.test", "version", "1.30.10"),
+					resource.TestCheckResourceAttr("cloudferro_kubernetes_cluster_v1.test", "control_plane.flavor", "eo2a.large"),
+					resource.TestCheckResourceAttrSet("cloudferro_kubernetes_cluster_v1.test", "kubeconfig"),
+					resource.TestCheckResourceAttrSet("cloudferro_kubernetes_cluster_v1.test", "router_ip"),
+					resource.TestCheckResourceAttrSet(
+						"cloudferro_kubernetes_cluster_v1.test", "metadata.openstack_project_id",
+					),
+				),
+			},
+			{
+				Config: testAccClusterConfig(provider, "acc-cluster", "1.31.6"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("cloudferro_kubernetes_cluster_v1.test", "version", "1.31.6"),
+				),
+			},
+			{
+				// the kubeconfig is stored by the next apply
+				ResourceName:            "cloudferro_kubernetes_cluster_v1.test",
+				ImportState:             true,
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"store_kubeconfig", "kubeconfig"},
+			},
+			{
+				ResourceName:            "cloudferro_kubernetes_cluster_v1.test",
+				ImportState:             true,
+				ImportStateId:           "acc-cluster",
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"store_kubeconfig", "kubeconfig"},
+			},
+		},
+	})
+}
+
+func TestAccKubernetesClusterV1_storeKubeconfig(t *testing.T) {
+	srv, provider := testAccFakeAPI(t, fakeapi.Options{})
+	config := testAccClusterConfig(provider, "acc-cluster", "1.30.10")
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccCheckClusterDestroy(srv),
+		Steps: []resource.TestStep{
+			{
+				Config: strings.Replace(config, "name    =", "store_kubeconfig = false\n  name    =", 1),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("cloudferro_kubernetes_cluster_v1.test", "store_kubeconfig", "false"),
+					resource.TestCheckNoResourceAttr("cloudferro_kubernetes_cluster_v1.test", "kubeconfig"),
+				),
+			},
+			{
+				// the kubeconfig is not imported either
+				ResourceName:      "cloudferro_kubernetes_cluster_v1.test",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+			{
+				Config: config,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("cloudferro_kubernetes_cluster_v1.test", "store_kubeconfig", "true"),
+					resource.TestCheckResourceAttrSet("cloudferro_kubernetes_cluster_v1.test", "kubeconfig"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccKubernetesClusterV1_apiError(t *testing.T) {
+	srv, provider := testAccFakeAPI(t, fakeapi.Options{})
+	srv.InjectError("CreateCluster", status.Error(codes.PermissionDenied, "project quota is locked"))
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccCheckClusterDestroy(srv),
+		Steps: []resource.TestStep{
+			{
+				Config:      testAccClusterConfig(provider, "acc-cluster", "1.30.10"),
+				ExpectError: regexp.MustCompile(`permission denied`),
+			},
+		},
+	})
+}
+
+func TestAccKubernetesClusterV1_errorState(t *testing.T) {
+	srv, provider := testAccFakeAPI(t, fakeapi.Options{})
+	srv.InjectErrorState("CreateCluster", "not enough floating ips")
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccCheckClusterDestroy(srv),
+		Steps: []resource.TestStep{
+			{
+				Config:      testAccClusterConfig(provider, "acc-cluster", "1.30.10"),
+				ExpectError: regexp.MustCompile(`not enough floating ips`),
+			},
+		},
+	})
+}
+
+func TestAccKubernetesClusterV1_notAllowed(t *testing.T) {
+	srv, provider := testAccFakeAPI(t, fakeapi.Options{})
+	provider = strings.Replace(provider, "  token", "  allowed_cluster_name_patterns = [\"team-a-*\"]\n  token", 1)
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccCheckClusterDestroy(srv),
+		Steps: []resource.TestStep{
+			{
+				Config:      testAccClusterConfig(provider, "acc-cluster", "1.30.10"),
+				ExpectError: regexp.MustCompile(`cluster is not allowed`),
+			},
+			{
+				Config: testAccClusterConfig(provider, "team-a-cluster", "1.30.10"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("cloudferro_kubernetes_cluster_v1.test", "name", "team-a-cluster"),
+				),
+			},
+		},
+	})
+}
@@ -3,11 +3,9 @@ package cloudferro
 import (
 	"context"
 	"regexp"
-	"strings"
-	"time"
 
-	"github.com/cloudferro/terraform-provider-cloudferro/internal/utils"
-	"github.com/google/uuid"
+	"github.com/cloudferro/terraform-provider-cloudferro/client"
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
 	"github.com/hashicorp/terraform-plugin-framework-validators/int32validator"
 	"github.com/hashicorp/terraform-plugin-framework-validators/listvalidator"
 	"github.com/hashicorp/terraform-plugin-framework-validators/resourcevalidator"
@@ -24,11 +22,7 @@ import (
 	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
-	"gitlab.cloudferro.com/k8s/api/machinespec/v1"
-	"gitlab.cloudferro.com/k8s/api/machinespecservice/v1"
 	"gitlab.cloudferro.com/k8s/api/nodepool/v1"
-	"gitlab.cloudferro.com/k8s/api/nodepoolservice/v1"
-	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
@@ -38,6 +32,7 @@ var (
 	_ resource.ResourceWithConfigure        = (*nodePoolResource)(nil)
 	_ resource.ResourceWithImportState      = (*nodePoolResource)(nil)
 	_ resource.ResourceWithConfigValidators = (*nodePoolResource)(nil)
+	_ resource.ResourceWithModifyPlan       = (*nodePoolResource)(nil)
 )
 
 func newNodePoolResource() resource.Resource {
@@ -56,22 +51,25 @@ type taintModel struct {
 }
 
 type nodePoolModel struct {
-	ClusterID      types.String `tfsdk:"cluster_id"`
-	ID             types.String `tfsdk:"id"`
-	Status         types.String `tfsdk:"-"`
-	Name           types.String `tfsdk:"name"`
-	Flavor         types.String `tfsdk:"flavor"`
-	Autoscale      types.Bool   `tfsdk:"autoscale"`
-	Size           types.Int32  `tfsdk:"size"`
-	SizeMin        types.Int32  `tfsdk:"size_min"`
-	SizeMax        types.Int32  `tfsdk:"size_max"`
-	SharedNetworks types.List   `tfsdk:"shared_networks"`
-	Taints         types.List   `tfsdk:"taints"`
-	Labels         types.List   `tfsdk:"labels"`
+	ClusterID      types.String   `tfsdk:"cluster_id"`
+	ID             types.String   `tfsdk:"id"`
+	Status         types.String   `tfsdk:"-"`
+	Name           types.String   `tfsdk:"name"`
+	Flavor         types.String   `tfsdk:"flavor"`
+	Autoscale      types.Bool     `tfsdk:"autoscale"`
+	Size           types.Int32    `tfsdk:"size"`
+	SizeMin        types.Int32    `tfsdk:"size_min"`
+	SizeMax        types.Int32    `tfsdk:"size_max"`
+	SharedNetworks types.List     `tfsdk:"shared_networks"`
+	Taints         types.List     `tfsdk:"taints"`
+	Labels         types.List     `tfsdk:"labels"`
+	Nodes          types.List     `tfsdk:"nodes"`
+	Region         types.String   `tfsdk:"region"`
+	Timeouts       timeouts.Value `tfsdk:"timeouts"`
 }
 
 type nodePoolResource struct {
-	cli *grpc.ClientConn
+	provider *providerState
 }
 
 // ConfigValidators implements resource.ResourceWithConfigValidators.
@@ -91,33 +89,48 @@ func (c *nodePoolResource) ImportState(
 	req resource.ImportStateRequest,
 	resp *resource.ImportStateResponse,
 ) {
-	parts := strings.Split(req.ID, "/")
-	if len(parts) != 2 {
+	ctx, end := startSpan(ctx, "cloudferro_kubernetes_node_pool_v1.ImportState")
+	defer end(&resp.Diagnostics)
+
+	region, parts := splitImportRegion(req.ID, 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
 		resp.Diagnostics.AddError(
 			"failed to import node pool state",
-			"id must be in the format of <cluster_id>/<node_pool_id>",
+			"id must be in the format of <cluster_id>/<node_pool_id> or <cluster_name>/<node_pool_name>, "+
+				"optionally prefixed with <region>/",
 		)
 		return
 	}
 
-	var err error
+	cli, diags := regionClient(c.provider, region)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
 
-	if _, err = uuid.Parse(parts[0]); err != nil {
-		resp.Diagnostics.AddError("failed to import node pool state", err.Error())
+	clusterID, err := cli.ResolveClusterID(ctx, parts[0])
+	if err != nil {
+		resp.Diagnostics.Append(resourceErrorDiagnostics(ctx, c, "failed to import node pool state", err)...)
 		return
 	}
 
-	if _, err = uuid.Parse(parts[1]); err != nil {
-		resp.Diagnostics.AddError("failed to import node pool state", err.Error())
+	nodePoolID, err := cli.ResolveNodePoolID(ctx, clusterID, parts[1])
+	if err != nil {
+		resp.Diagnostics.Append(resourceErrorDiagnostics(ctx, c, "failed to import node pool state", err)...)
 		return
 	}
 
 	var state nodePoolModel
 
-	state.ClusterID = types.StringValue(parts[0])
-	state.ID = types.StringValue(parts[1])
+	state.ClusterID = types.StringValue(clusterID)
+	state.ID = types.StringValue(nodePoolID)
+	state.Region = region
+	if region.IsNull() {
+		state.Region = c.provider.defaultRegion()
+	}
+	state.Timeouts = nullTimeouts(ctx)
 
-	resp.Diagnostics.Append(refreshNodePoolState(ctx, c.cli, &state)...)
+	resp.Diagnostics.Append(refreshNodePoolState(ctx, cli, &state)...)
 	if resp.Diagnostics.HasError() {
 		return
 	}
@@ -143,26 +156,27 @@ func (c *nodePoolResource) Configure(
 		resp.Diagnostics.AddError("failed to configure resource", "invalid provider data type")
 		return
 	}
-	c.cli = state.Cli
+	c.provider = state
 }
 
-func refreshNodePoolState(ctx context.Context, cli *grpc.ClientConn, state *nodePoolModel) diag.Diagnostics {
+func refreshNodePoolState(ctx context.Context, cli *client.Client, state *nodePoolModel) diag.Diagnostics {
 	var diags diag.Diagnostics
-	clusterID := state.ClusterID.ValueString()
-	nodePoolID := state.ID.ValueString()
-
-	nodePoolCli := nodepoolservice.NewNodePoolClient(cli)
 
 	tflog.Debug(ctx, "refresh state, getting node pool")
-	nodePool, err := nodePoolCli.GetNodePool(ctx, &nodepoolservice.GetNodePoolRequest{
-		ClusterId:  clusterID,
-		NodePoolId: nodePoolID,
-	})
+	nodePool, err := cli.GetNodePool(ctx, state.ClusterID.ValueString(), state.ID.ValueString())
 	if err != nil {
-		diags.AddError("failed to refresh node pool state", err.Error())
+		diags.Append(apiErrorDiagnostics(ctx, "failed to refresh node pool state", err)...)
 		return diags
 	}
 
+	return setNodePoolState(ctx, nodePool, state)
+}
+
+// setNodePoolState copies the node pool into the state.
+func setNodePoolState(ctx context.Context, nodePool *nodepool.NodePool, state *nodePoolModel) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	state.ID = types.StringValue(nodePool.GetId())
 	state.Status = types.StringValue(nodePool.GetStatus())
 	state.Flavor = types.StringValue(nodePool.GetMachineSpec().GetName())
 	state.Name = types.StringValue(nodePool.GetName())
@@ -250,24 +264,49 @@ func refreshNodePoolState(ctx context.Context, cli *grpc.ClientConn, state *node
 		state.Taints = types.ListValueMust(types.ObjectType{AttrTypes: taintsInnerType}, taints)
 	}
 
+	nodesInnerType := map[string]attr.Type{
+		"name":       types.StringType,
+		"server_id":  types.StringType,
+		"private_ip": types.StringType,
+		"status":     types.StringType,
+	}
+
+	tflog.Debug(ctx, "refresh state, parsing nodes")
+	nodes := []attr.Value{}
+	for _, el := range nodePool.GetNodes() {
+		obj, diag := types.ObjectValue(
+			nodesInnerType,
+			map[string]attr.Value{
+				"name":       types.StringValue(el.GetName()),
+				"server_id":  types.StringValue(el.GetServerId()),
+				"private_ip": types.StringValue(el.GetPrivateIp()),
+				"status":     types.StringValue(el.GetStatus()),
+			},
+		)
+		diags.Append(diag...)
+		if diags.HasError() {
+			return diags
+		}
+
+		nodes = append(nodes, obj)
+	}
+
+	state.Nodes = types.ListValueMust(types.ObjectType{AttrTypes: nodesInnerType}, nodes)
+
 	return diags
 }
 
 // Create implements resource.Resource.
 func (c *nodePoolResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	ctx, end := startSpan(ctx, "cloudferro_kubernetes_node_pool_v1.Create")
+	defer end(&resp.Diagnostics)
+
 	var state nodePoolModel
 	resp.Diagnostics.Append(req.Plan.Get(ctx, &state)...)
 	if resp.Diagnostics.HasError() {
 		return
 	}
 
-	mutex := getResourceMutex(state.ClusterID.ValueString())
-	mutex.Lock()
-	defer mutex.Unlock()
-
-	npCli := nodepoolservice.NewNodePoolClient(c.cli)
-	msCli := machinespecservice.NewMachineSpecClient(c.cli)
-
 	var sharedNetworks []string
 	var labels []*nodepool.Label
 	var taints []*nodepool.Taint
@@ -319,97 +358,52 @@ func (c *nodePoolResource) Create(ctx context.Context, req resource.CreateReques
 		}
 	}
 
-	var machineSpec *machinespec.MachineSpec
-	machineSpecs, err := msCli.List(ctx, &machinespecservice.ListRequest{
-		Name: state.Flavor.ValueStringPointer(),
-	})
-	if err != nil {
-		resp.Diagnostics.AddError("failed to create node pool", err.Error())
-		return
-	}
-
-	for _, el := range machineSpecs.Items {
-		if el.GetName() == state.Flavor.ValueString() {
-			machineSpec = el
-			break
-		}
-	}
-
-	if machineSpec == nil {
-		resp.Diagnostics.AddError("failed to create node pool", "flavor not found")
-		return
-	}
-
-	clusterID := state.ClusterID.ValueString()
-
-	ticker := time.NewTicker(10 * time.Second)
-	defer ticker.Stop()
-
-	nodePool, err := npCli.CreateNodePool(ctx, &nodepoolservice.CreateNodePoolRequest{
-		ClusterId: clusterID,
-		NodePool: &nodepoolservice.NodePoolCreate{
-			MachineSpec: &nodepoolservice.NodePoolCreate_MachineSpec{
-				Id: machineSpec.Id,
-			},
-			Name:           state.Name.ValueStringPointer(),
-			Size:           state.Size.ValueInt32Pointer(),
-			SizeMin:        state.SizeMin.ValueInt32Pointer(),
-			SizeMax:        state.SizeMax.ValueInt32Pointer(),
-			Autoscale:      state.Autoscale.ValueBool(),
-			SharedNetworks: sharedNetworks,
-			Labels:         labels,
-			Taints:         taints,
-		},
-	})
-	if err != nil {
-		resp.Diagnostics.AddError("failed to create node pool", err.Error())
+	ctx, cancel, diags := withTimeout(ctx, state.Timeouts.Create)
+	defer cancel()
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
 		return
 	}
 
-	// update current state with id's
-	state.ID = types.StringValue(nodePool.Id)
-	resp.Diagnostics.Append(refreshNodePoolState(ctx, c.cli, &state)...)
+	cli, diags := regionClient(c.provider, state.Region)
+	resp.Diagnostics.Append(diags...)
 	if resp.Diagnostics.HasError() {
 		return
 	}
 
-	resp.Diagnostics.Append(resp.State.Set(ctx, state)...)
+	// the cluster may have been unknown when the plan was checked
+	resp.Diagnostics.Append(c.provider.checkClusterAllowed(ctx, cli, state.ClusterID, types.StringNull())...)
 	if resp.Diagnostics.HasError() {
 		return
 	}
 
-	for {
-		resp.Diagnostics.Append(refreshNodePoolState(ctx, c.cli, &state)...)
-		if resp.Diagnostics.HasError() {
-			return
-		}
-
+	_, err := cli.CreateNodePool(ctx, state.ClusterID.ValueString(), client.NodePoolSpec{
+		Name:           state.Name.ValueString(),
+		Flavor:         state.Flavor.ValueString(),
+		Autoscale:      state.Autoscale.ValueBool(),
+		Size:           state.Size.ValueInt32Pointer(),
+		SizeMin:        state.SizeMin.ValueInt32Pointer(),
+		SizeMax:        state.SizeMax.ValueInt32Pointer(),
+		SharedNetworks: sharedNetworks,
+		Labels:         labels,
+		Taints:         taints,
+	}, func(nodePool *nodepool.NodePool) {
+		// keep the state up to date, so the node pool is tracked even if
+		// creation fails or is interrupted
+		resp.Diagnostics.Append(setNodePoolState(ctx, nodePool, &state)...)
 		resp.Diagnostics.Append(resp.State.Set(ctx, state)...)
-		if resp.Diagnostics.HasError() {
-			return
-		}
-
-		if state.Status.ValueString() == "Error" {
-			errStr := "Unknown error"
-			resp.Diagnostics.AddError("failed to create node pool", errStr)
-			return
-		} else if state.Status.ValueString() == "Running" {
-			break
-		}
-
-		select {
-		case <-ctx.Done():
-			resp.Diagnostics.AddError("failed to create node pool", ctx.Err().Error())
-			return
-
-		case <-ticker.C:
-			continue
-		}
+	})
+	if err != nil {
+		resp.Diagnostics.Append(resourceErrorDiagnostics(ctx, c, "failed to create node pool", err)...)
+		return
 	}
 }
 
 // Delete implements resource.Resource.
 func (c *nodePoolResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	ctx, end := startSpan(ctx, "cloudferro_kubernetes_node_pool_v1.Delete")
+	defer end(&resp.Diagnostics)
+
 	var state nodePoolModel
 	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
 	if resp.Diagnostics.HasError() {
@@ -421,104 +415,23 @@ func (c *nodePoolResource) Delete(ctx context.Context, req resource.DeleteReques
 		return
 	}
 
-	mutex := getResourceMutex(state.ClusterID.ValueString())
-	mutex.Lock()
-	defer mutex.Unlock()
-
-	nodePoolID := state.ID.ValueString()
-	clusterID := state.ClusterID.ValueString()
-
-	cli := nodepoolservice.NewNodePoolClient(c.cli)
-
-	tflog.Debug(ctx, "determining state of the node pool")
-	nodePool, err := cli.GetNodePool(ctx, &nodepoolservice.GetNodePoolRequest{
-		ClusterId:  clusterID,
-		NodePoolId: nodePoolID,
-	})
-	if st, ok := status.FromError(err); ok && st.Code() == codes.NotFound {
-		tflog.Info(ctx, "node pool not found, nothing to delete")
-		return
-	} else if err != nil {
-		resp.Diagnostics.AddError("failed to delete node pool", err.Error())
+	ctx, cancel, diags := withTimeout(ctx, state.Timeouts.Delete)
+	defer cancel()
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
 		return
 	}
 
-	if nodePool.Status == "Running" || nodePool.Status == "Error" {
-		tflog.Debug(ctx, "node pool is running or in error, deleting it")
-		_, err = cli.DeleteNodePool(ctx, &nodepoolservice.DeleteNodePoolRequest{
-			ClusterId:  clusterID,
-			NodePoolId: nodePoolID,
-		})
-		if st, ok := status.FromError(err); ok && st.Code() == codes.NotFound {
-			tflog.Info(ctx, "node pool not found, nothing to delete")
-			return
-		} else if err != nil {
-			resp.Diagnostics.AddError("failed to delete node pool", err.Error())
-			return
-		}
-
-	} else if nodePool.Status != "Deleting" {
-		lastErr, err := utils.GetLatestClusterError(ctx, c.cli, state.ClusterID.ValueString())
-		if err != nil {
-			resp.Diagnostics.AddError("failed to refresh delete node pool", err.Error())
-		}
-
-		if lastErr != nil {
-			resp.Diagnostics.AddError("failed to delete node pool", lastErr.Msg)
-		} else {
-			resp.Diagnostics.AddError(
-				"failed to delete node pool",
-				"cluster in the invalid state",
-			)
-		}
+	cli, diags := regionClient(c.provider, state.Region)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
 		return
 	}
 
-	ticker := time.NewTicker(10 * time.Second)
-	defer ticker.Stop()
-
-	for {
-
-		tflog.Debug(ctx, "getting current state of node pool")
-		np, err := cli.GetNodePool(ctx, &nodepoolservice.GetNodePoolRequest{
-			ClusterId:  clusterID,
-			NodePoolId: nodePoolID,
-		})
-		if st, ok := status.FromError(err); ok && st.Code() == codes.NotFound {
-			return
-		} else if err != nil {
-			resp.Diagnostics.AddError("failed to delete node pool", err.Error())
-			return
-		}
-
-		if np.Status == "Error" {
-			lastErr, err := utils.GetLatestClusterError(ctx, c.cli, clusterID)
-			if err != nil {
-				resp.Diagnostics.AddError("failed to delete node pool", "unknown error")
-				return
-			}
-
-			if lastErr != nil {
-				resp.Diagnostics.AddError("failed to delete node pool", lastErr.Msg)
-			} else {
-				resp.Diagnostics.AddError(
-					"failed to delete node pool",
-					"cluster in the invalid state",
-				)
-			}
-
-			return
-		}
-
-		tflog.Debug(ctx, "node pool is still deleting, waiting 10s")
-
-		select {
-		case <-ctx.Done():
-			resp.Diagnostics.AddError("failed to delete node pool", ctx.Err().Error())
-			return
-		case <-ticker.C:
-			continue
-		}
+	err := cli.DeleteNodePool(ctx, state.ClusterID.ValueString(), state.ID.ValueString())
+	if err != nil {
+		resp.Diagnostics.Append(resourceErrorDiagnostics(ctx, c, "failed to delete node pool", err)...)
+		return
 	}
 }
 
@@ -531,8 +444,44 @@ func (c *nodePoolResource) Metadata(
 	resp.TypeName = req.ProviderTypeName + "_kubernetes_node_pool_v1"
 }
 
+// ModifyPlan implements resource.ResourceWithModifyPlan. Changes of node
+// pools of clusters the provider is not allowed to change fail at plan time.
+func (c *nodePoolResource) ModifyPlan(
+	ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse,
+) {
+	if c.provider == nil {
+		return
+	}
+
+	c.provider.modifyRegionPlan(ctx, req, resp)
+	if resp.Diagnostics.HasError() || c.provider.allowlist.empty() || resp.Plan.Raw.Equal(req.State.Raw) {
+		return
+	}
+
+	var nodePool nodePoolModel
+	if req.State.Raw.IsNull() {
+		resp.Diagnostics.Append(resp.Plan.Get(ctx, &nodePool)...)
+	} else {
+		resp.Diagnostics.Append(req.State.Get(ctx, &nodePool)...)
+	}
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	cli, diags := regionClient(c.provider, nodePool.Region)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(c.provider.checkClusterAllowed(ctx, cli, nodePool.ClusterID, types.StringNull())...)
+}
+
 // Read implements resource.Resource.
 func (c *nodePoolResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	ctx, end := startSpan(ctx, "cloudferro_kubernetes_node_pool_v1.Read")
+	defer end(&resp.Diagnostics)
+
 	var state nodePoolModel
 	tflog.Debug(ctx, "read, getting state")
 	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
@@ -545,8 +494,32 @@ func (c *nodePoolResource) Read(ctx context.Context, req resource.ReadRequest, r
 		return
 	}
 
+	// resources created before the region was computed have none
+	if state.Region.IsNull() {
+		state.Region = c.provider.defaultRegion()
+	}
+
+	cli, diags := regionClient(c.provider, state.Region)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	tflog.Debug(ctx, "read, refreshing node pool state")
-	resp.Diagnostics.Append(refreshNodePoolState(ctx, c.cli, &state)...)
+	nodePool, err := cli.GetNodePool(ctx, state.ClusterID.ValueString(), state.ID.ValueString())
+	if status.Code(err) == codes.NotFound {
+		// deleted outside of terraform, or by a replacement that failed
+		tflog.Warn(ctx, "node pool not found, removing it from the state", map[string]any{
+			"id": state.ID.ValueString(),
+		})
+		resp.State.RemoveResource(ctx)
+		return
+	} else if err != nil {
+		resp.Diagnostics.Append(resourceErrorDiagnostics(ctx, c, "failed to refresh node pool state", err)...)
+		return
+	}
+
+	resp.Diagnostics.Append(setNodePoolState(ctx, nodePool, &state)...)
 	if resp.Diagnostics.HasError() {
 		return
 	}
@@ -628,10 +601,18 @@ func (c *nodePoolResource) Schema(ctx context.Context, req resource.SchemaReques
 				},
 			},
 			"shared_networks": schema.ListAttribute{
-				Description:   "A list of network ids that should be attached to the nodes in the node pool.",
-				ElementType:   types.StringType,
-				Optional:      true,
-				PlanModifiers: []planmodifier.List{listplanmodifier.RequiresReplace()},
+				Description: "A list of network ids that should be attached to the nodes in the node pool. " +
+					"Changes are rolled out to the existing nodes without replacing the node pool. Where the " +
+					"service cannot attach networks in place, the nodes are replaced one at a time through a " +
+					"temporary node pool and the node pool gets a new id.",
+				ElementType: types.StringType,
+				Optional:    true,
+				Validators: []validator.List{
+					listvalidator.UniqueValues(),
+					listvalidator.ValueStringsAre(
+						stringvalidator.RegexMatches(uuidRegex, "must be valid uuid"),
+					),
+				},
 			},
 			"labels": schema.ListNestedAttribute{
 				Description: "List of labels. Must followe standard kubernetes requirements.",
@@ -683,12 +664,41 @@ func (c *nodePoolResource) Schema(ctx context.Context, req resource.SchemaReques
 					},
 				},
 			},
+			"nodes": schema.ListNestedAttribute{
+				Description: "List of nodes currently running in the node pool.",
+				Computed:    true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"name": schema.StringAttribute{
+							Computed:    true,
+							Description: "Name of the node.",
+						},
+						"server_id": schema.StringAttribute{
+							Computed:    true,
+							Description: "Id of the underlying OpenStack server.",
+						},
+						"private_ip": schema.StringAttribute{
+							Computed:    true,
+							Description: "Private IP address of the node.",
+						},
+						"status": schema.StringAttribute{
+							Computed:    true,
+							Description: "Status of the node.",
+						},
+					},
+				},
+			},
+			"region":   regionAttribute(),
+			"timeouts": timeoutsAttribute(ctx),
 		},
 	}
 }
 
 // Update implements resource.Resource.
 func (c *nodePoolResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	ctx, end := startSpan(ctx, "cloudferro_kubernetes_node_pool_v1.Update")
+	defer end(&resp.Diagnostics)
+
 	var current nodePoolModel
 	resp.Diagnostics.Append(req.State.Get(ctx, &current)...)
 	if resp.Diagnostics.HasError() {
@@ -701,84 +711,69 @@ func (c *nodePoolResource) Update(ctx context.Context, req resource.UpdateReques
 		return
 	}
 
-	mutex := getResourceMutex(current.ClusterID.ValueString())
-	mutex.Lock()
-	defer mutex.Unlock()
-
-	clusterID := current.ClusterID.ValueString()
-	nodePoolID := current.ID.ValueString()
-
-	cli := nodepoolservice.NewNodePoolClient(c.cli)
-
-	nodePool, err := cli.GetNodePool(ctx, &nodepoolservice.GetNodePoolRequest{
-		ClusterId:  clusterID,
-		NodePoolId: nodePoolID,
-	})
-	if err != nil {
-		resp.Diagnostics.AddError("failed to update node pool", err.Error())
+	ctx, cancel, diags := withTimeout(ctx, request.Timeouts.Update)
+	defer cancel()
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
 		return
 	}
 
-	nodePool.Autoscale = request.Autoscale.ValueBool()
-	nodePool.Size = request.Size.ValueInt32Pointer()
-	nodePool.SizeMin = request.SizeMin.ValueInt32Pointer()
-	nodePool.SizeMax = request.SizeMax.ValueInt32Pointer()
-
-	ticker := time.NewTicker(10 * time.Second)
-	defer ticker.Stop()
-
-	tflog.Info(ctx, "updating node pool", map[string]any{"object": nodePool})
-	_, err = cli.UpdateNodePool(ctx, &nodepoolservice.UpdateNodePoolRequest{
-		ClusterId:  clusterID,
-		NodePoolId: nodePoolID,
-		NodePool:   nodePool,
-	})
-	if err != nil {
-		resp.Diagnostics.AddError("failed to update node pool", err.Error())
+	// the state is the planned one until the node pool is changed, keep the
+	// prior one should the update fail before
+	resp.Diagnostics.Append(resp.State.Set(ctx, &current)...)
+	if resp.Diagnostics.HasError() {
 		return
 	}
 
-	for {
+	spec := client.NodePoolSpec{
+		Autoscale: request.Autoscale.ValueBool(),
+		Size:      request.Size.ValueInt32Pointer(),
+		SizeMin:   request.SizeMin.ValueInt32Pointer(),
+		SizeMax:   request.SizeMax.ValueInt32Pointer(),
+	}
 
-		resp.Diagnostics.Append(refreshNodePoolState(ctx, c.cli, &current)...)
-		if resp.Diagnostics.HasError() {
-			return
+	networksChanged := !request.SharedNetworks.Equal(current.SharedNetworks)
+	if networksChanged {
+		var sharedNetworksElems []types.String
+		if !request.SharedNetworks.IsNull() && !request.SharedNetworks.IsUnknown() {
+			resp.Diagnostics.Append(request.SharedNetworks.ElementsAs(ctx, &sharedNetworksElems, false)...)
+			if resp.Diagnostics.HasError() {
+				return
+			}
 		}
 
-		resp.Diagnostics.Append(resp.State.Set(ctx, &current)...)
-		if resp.Diagnostics.HasError() {
-			return
+		spec.SharedNetworks = make([]string, 0, len(sharedNetworksElems))
+		for _, el := range sharedNetworksElems {
+			spec.SharedNetworks = append(spec.SharedNetworks, el.ValueString())
 		}
 
-		if current.Status.ValueString() == "Running" {
-			break
-		}
+		current.SharedNetworks = request.SharedNetworks
+	}
 
-		if current.Status.ValueString() == "Error" {
-			lastErr, err := utils.GetLatestClusterError(ctx, c.cli, clusterID)
-			if err != nil {
-				resp.Diagnostics.AddError("failed to update node pool", "unknown error")
-				return
-			}
+	cli, diags := regionClient(c.provider, current.Region)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
 
-			if lastErr != nil {
-				resp.Diagnostics.AddError("failed to update node pool", lastErr.Msg)
-			} else {
-				resp.Diagnostics.AddError(
-					"failed to update node pool",
-					"cluster in the invalid state",
-				)
-			}
-			return
+	// the same region, possibly spelled otherwise
+	current.Region = request.Region
+	current.Timeouts = request.Timeouts
 
-		}
+	progress := func(nodePool *nodepool.NodePool) {
+		resp.Diagnostics.Append(setNodePoolState(ctx, nodePool, &current)...)
+		resp.Diagnostics.Append(resp.State.Set(ctx, &current)...)
+	}
 
-		select {
-		case <-ctx.Done():
-			resp.Diagnostics.AddError("failed to update node pool", ctx.Err().Error())
-			return
-		case <-ticker.C:
-			continue
-		}
+	_, err := cli.UpdateNodePool(ctx, current.ClusterID.ValueString(), current.ID.ValueString(), spec, progress)
+	if networksChanged && status.Code(err) == codes.Unimplemented {
+		tflog.Info(ctx, "shared networks cannot be changed in place, replacing the node pool", map[string]any{
+			"error": err.Error(),
+		})
+		_, err = cli.ReplaceNodePool(ctx, current.ClusterID.ValueString(), current.ID.ValueString(), spec, progress)
+	}
+	if err != nil {
+		resp.Diagnostics.Append(resourceErrorDiagnostics(ctx, c, "failed to update node pool", err)...)
+		return
 	}
 }
@@ -0,0 +1,240 @@
+package cloudferro
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/cloudferro/terraform-provider-cloudferro/client"
+	"github.com/cloudferro/terraform-provider-cloudferro/internal/fakeapi"
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/plancheck"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const testAccSharedNetworkID = "6f3d5a52-3b0e-4a38-9d4c-2a1d1c2b7e01"
+
+func testAccNodePoolConfig(provider string, size int, sharedNetworks string) string {
+	return testAccClusterConfig(provider, "acc-cluster", "1.30.10") + fmt.Sprintf(`
+resource "cloudferro_kubernetes_node_pool_v1" "test" {
+  cluster_id      = cloudferro_kubernetes_cluster_v1.test.id
+  name            = "acc-pool"
+  flavor          = "eo2a.xlarge"
+  size            = %d
+  shared_networks = %s
+  labels = [
+    { key = "role", value = "worker" },
+  ]
+  taints = [
+    { key = "dedicated", value = "batch", effect = "NoSchedule" },
+  ]
+}
+`, size, sharedNetworks)
+}
+
+func testAccCheckNodePoolDestroy(srv *fakeapi.Server) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		for _, rs := range s.RootModule().Resources {
+			if rs.Type != "cloudferro_kubernetes_node_pool_v1" {
+				continue
+			}
+
+			if srv.NodePoolExists(rs.Primary.Attributes["cluster_id"], rs.Primary.ID) {
+				return fmt.Errorf("node pool %s still exists", rs.Primary.ID)
+			}
+		}
+
+		return testAccCheckClusterDestroy(srv)(s)
+	}
+}
+
+func testAccNodePoolImportID(names bool) resource.ImportStateIdFunc {
+	return func(s *terraform.State) (string, error) {
+		rs, ok := s.RootModule().Resources["cloudferro_kubernetes_node_pool_v1.test"]
+		if !ok {
+			return "", fmt.Errorf("node pool not found in state")
+		}
+
+		if names {
+			return "acc-cluster/" + rs.Primary.Attributes["name"], nil
+		}
+
+		return rs.Primary.Attributes["cluster_id"] + "/" + rs.Primary.ID, nil
+	}
+}
+
+func TestAccKubernetesNodePoolV1_basic(t *testing.T) {
+	srv, provider := testAccFakeAPI(t, fakeapi.Options{
+		Latency: 10 * time.Millisecond,
+	})
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccCheckNodePoolDestroy(srv),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccNodePoolConfig(provider, 2, "[]"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttrSet("cloudferro_kubernetes_node_pool_v1.test", "id"),
+					resource.TestCheckResourceAttr("cloudferro_kubernetes_node_pool_v1.test", "size", "2"),
+					resource.TestCheckResourceAttr("cloudferro_kubernetes_node_pool_v1.test", "nodes.#", "2"),
+					resource.TestCheckResourceAttrSet("cloudferro_kubernetes_node_pool_v1.test", "nodes.0.server_id"),
+					resource.TestCheckResourceAttr("cloudferro_kubernetes_node_pool_v1.test", "labels.0.key", "role"),
+					resource.TestCheckResourceAttr(
+						"cloudferro_kubernetes_node_pool_v1.test", "taints.0.effect", "NoSchedule",
+					),
+				),
+			},
+			{
+				Config: testAccNodePoolConfig(
+					provider, 3, fmt.Sprintf("[%q, %q]", testAccSharedNetworkID, testAccSharedNetworkID),
+				),
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile("duplicate values"),
+			},
+			{
+				Config: testAccNodePoolConfig(provider, 3, fmt.Sprintf("[%q]", testAccSharedNetworkID)),
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectResourceAction(
+							"cloudferro_kubernetes_node_pool_v1.test", plancheck.ResourceActionUpdate,
+						),
+					},
+				},
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("cloudferro_kubernetes_node_pool_v1.test", "size", "3"),
+					resource.TestCheckResourceAttr("cloudferro_kubernetes_node_pool_v1.test", "nodes.#", "3"),
+					resource.TestCheckResourceAttr("cloudferro_kubernetes_node_pool_v1.test", "shared_networks.#", "1"),
+					resource.TestCheckResourceAttr(
+						"cloudferro_kubernetes_node_pool_v1.test", "shared_networks.0", testAccSharedNetworkID,
+					),
+				),
+			},
+			{
+				ResourceName:            "cloudferro_kubernetes_node_pool_v1.test",
+				ImportState:             true,
+				ImportStateIdFunc:       testAccNodePoolImportID(false),
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"labels", "taints", "shared_networks"},
+			},
+			{
+				ResourceName:            "cloudferro_kubernetes_node_pool_v1.test",
+				ImportState:             true,
+				ImportStateIdFunc:       testAccNodePoolImportID(true),
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"labels", "taints", "shared_networks"},
+			},
+		},
+	})
+}
+
+func TestAccKubernetesNodePoolV1_replaceNetworks(t *testing.T) {
+	srv, provider := testAccFakeAPI(t, fakeapi.Options{})
+
+	var nodePoolID string
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccCheckNodePoolDestroy(srv),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccNodePoolConfig(provider, 2, "[]"),
+				Check: func(s *terraform.State) error {
+					nodePoolID = s.RootModule().Resources["cloudferro_kubernetes_node_pool_v1.test"].Primary.ID
+					return nil
+				},
+			},
+			{
+				PreConfig: func() {
+					srv.InjectError("UpdateNodePool", status.Error(codes.Unimplemented, "no hot attach"))
+				},
+				Config: testAccNodePoolConfig(provider, 2, fmt.Sprintf("[%q]", testAccSharedNetworkID)),
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectResourceAction(
+							"cloudferro_kubernetes_node_pool_v1.test", plancheck.ResourceActionUpdate,
+						),
+					},
+				},
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("cloudferro_kubernetes_node_pool_v1.test", "name", "acc-pool"),
+					resource.TestCheckResourceAttr("cloudferro_kubernetes_node_pool_v1.test", "nodes.#", "2"),
+					resource.TestCheckResourceAttr(
+						"cloudferro_kubernetes_node_pool_v1.test", "shared_networks.0", testAccSharedNetworkID,
+					),
+					func(s *terraform.State) error {
+						rs := s.RootModule().Resources["cloudferro_kubernetes_node_pool_v1.test"]
+						if rs.Primary.ID == nodePoolID {
+							return fmt.Errorf("node pool %s was not replaced", nodePoolID)
+						}
+						if srv.NodePoolExists(rs.Primary.Attributes["cluster_id"], nodePoolID) {
+							return fmt.Errorf("replaced node pool %s still exists", nodePoolID)
+						}
+						return nil
+					},
+				),
+			},
+		},
+	})
+}
+
+func TestAccKubernetesNodePoolV1_deleted(t *testing.T) {
+	srv, provider := testAccFakeAPI(t, fakeapi.Options{})
+
+	var clusterID, nodePoolID string
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccCheckNodePoolDestroy(srv),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccNodePoolConfig(provider, 1, "[]"),
+				Check: func(s *terraform.State) error {
+					rs := s.RootModule().Resources["cloudferro_kubernetes_node_pool_v1.test"]
+					clusterID, nodePoolID = rs.Primary.Attributes["cluster_id"], rs.Primary.ID
+					return nil
+				},
+			},
+			{
+				// deleted outside of terraform, it is planned to be created again
+				PreConfig: func() {
+					cli := client.New(srv.ClientConn(), client.Options{PollInterval: 10 * time.Millisecond})
+					if err := cli.DeleteNodePool(context.Background(), clusterID, nodePoolID); err != nil {
+						t.Fatalf("DeleteNodePool() error = %v", err)
+					}
+				},
+				Config:             testAccNodePoolConfig(provider, 1, "[]"),
+				PlanOnly:           true,
+				ExpectNonEmptyPlan: true,
+			},
+		},
+	})
+}
+
+func TestAccKubernetesNodePoolV1_timeout(t *testing.T) {
+	_, provider := testAccFakeAPI(t, fakeapi.Options{
+		Latency: 10 * time.Millisecond,
+	})
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccClusterConfig(provider, "acc-cluster", "1.30.10") + `
+resource "cloudferro_kubernetes_node_pool_v1" "test" {
+  cluster_id = cloudferro_kubernetes_cluster_v1.test.id
+  name       = "acc-pool"
+  flavor     = "eo2a.xlarge"
+  size       = 1
+  timeouts = {
+    create = "1ms"
+  }
+}
+`,
+				ExpectError: regexp.MustCompile("deadline exceeded"),
+			},
+		},
+	})
+}
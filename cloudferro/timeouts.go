@@ -0,0 +1,46 @@
+package cloudferro
+
+import (
+	"context"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// defaultTimeout bounds the operations of the resources without a timeouts
+// attribute, including the waits for the cluster to accept them.
+const defaultTimeout = time.Hour
+
+// timeoutsOpts are the operations of the resources that can be bounded.
+var timeoutsOpts = timeouts.Opts{Create: true, Update: true, Delete: true}
+
+// timeoutsAttribute is the timeouts attribute of the resources.
+func timeoutsAttribute(ctx context.Context) schema.Attribute {
+	return timeouts.Attributes(ctx, timeoutsOpts)
+}
+
+// nullTimeouts returns the timeouts of a resource that has none configured,
+// such as an imported one.
+func nullTimeouts(ctx context.Context) timeouts.Value {
+	t, _ := timeoutsAttribute(ctx).GetType().(timeouts.Type)
+
+	return timeouts.Value{Object: types.ObjectNull(t.AttrTypes)}
+}
+
+// withTimeout bounds ctx by the timeout of an operation, one of the methods of
+// timeouts.Value such as timeouts.Value.Create.
+func withTimeout(
+	ctx context.Context,
+	timeout func(context.Context, time.Duration) (time.Duration, diag.Diagnostics),
+) (context.Context, context.CancelFunc, diag.Diagnostics) {
+	d, diags := timeout(ctx, defaultTimeout)
+	if diags.HasError() {
+		return ctx, func() {}, diags
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, d)
+	return ctx, cancel, diags
+}
@@ -0,0 +1,27 @@
+package cloudferro
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/cloudferro/terraform-provider-cloudferro/cloudferro")
+
+// startSpan starts the span of an operation of a resource and records its
+// calls for the diagnostics. The returned function ends it, as failed when
+// diags has errors.
+func startSpan(ctx context.Context, name string) (context.Context, func(diags *diag.Diagnostics)) {
+	ctx, span := tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindInternal))
+	ctx = withLastCall(ctx)
+
+	return ctx, func(diags *diag.Diagnostics) {
+		if errs := diags.Errors(); len(errs) > 0 {
+			span.SetStatus(codes.Error, errs[0].Summary())
+		}
+		span.End()
+	}
+}
@@ -0,0 +1,37 @@
+package cloudferro
+
+import (
+	"context"
+
+	"github.com/cloudferro/terraform-provider-cloudferro/internal/k8sversion"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+)
+
+// kubernetesVersionValidator checks that a version is a Kubernetes version
+// such as 1.31.6.
+type kubernetesVersionValidator struct{}
+
+var _ validator.String = kubernetesVersionValidator{}
+
+// Description implements validator.String.
+func (kubernetesVersionValidator) Description(context.Context) string {
+	return "must be a valid version"
+}
+
+// MarkdownDescription implements validator.String.
+func (v kubernetesVersionValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+// ValidateString implements validator.String.
+func (kubernetesVersionValidator) ValidateString(
+	ctx context.Context, req validator.StringRequest, resp *validator.StringResponse,
+) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+
+	if _, err := k8sversion.Parse(req.ConfigValue.ValueString()); err != nil {
+		resp.Diagnostics.AddAttributeError(req.Path, "invalid version", err.Error())
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/cloudferro/terraform-provider-cloudferro/client"
+)
+
+func runClustersList(ctx context.Context, a *app, _ []string) error {
+	clusters, err := a.client.ListClusters(ctx)
+	if err != nil {
+		return err
+	}
+
+	views := make([]clusterView, 0, len(clusters))
+	for _, el := range clusters {
+		views = append(views, newClusterView(el))
+	}
+
+	slices.SortFunc(views, func(a, b clusterView) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return a.print(views, func(w *tabwriter.Writer) {
+		row(w, "ID", "NAME", "STATUS", "VERSION", "CONTROL PLANE")
+		for _, el := range views {
+			row(w, el.ID, el.Name, el.Status, el.Version,
+				fmt.Sprintf("%d x %s", el.ControlPlaneSize, el.ControlPlaneFlavor))
+		}
+	})
+}
+
+func runClustersGet(ctx context.Context, a *app, args []string) error {
+	clusterID, err := a.client.ResolveClusterID(ctx, args[0])
+	if err != nil {
+		return err
+	}
+
+	klaster, err := a.client.GetCluster(ctx, clusterID)
+	if err != nil {
+		return err
+	}
+
+	view := newClusterView(klaster)
+	if client.Status(klaster.GetStatus()) == client.StatusError {
+		view.LatestError = newErrorView(client.LatestError(klaster))
+	}
+
+	return a.print(view, func(w *tabwriter.Writer) {
+		row(w, "ID:", view.ID)
+		row(w, "Name:", view.Name)
+		row(w, "Status:", view.Status)
+		row(w, "Version:", view.Version)
+		row(w, "Control plane:", fmt.Sprintf("%d x %s", view.ControlPlaneSize, view.ControlPlaneFlavor))
+		row(w, "Router IP:", view.RouterIP)
+		row(w, "OpenStack project:", view.OpenstackProjectID)
+		if view.LatestError != nil {
+			row(w, "Latest error:", view.LatestError.Message)
+		}
+	})
+}
+
+func clustersDeleteFlags(fs *flag.FlagSet, a *app) {
+	fs.BoolVar(&a.yes, "yes", false, "do not ask for confirmation")
+}
+
+func runClustersDelete(ctx context.Context, a *app, args []string) error {
+	clusterID, err := a.client.ResolveClusterID(ctx, args[0])
+	if err != nil {
+		return err
+	}
+
+	klaster, err := a.client.GetCluster(ctx, clusterID)
+	if err != nil {
+		return err
+	}
+
+	if !a.yes {
+		fmt.Fprintf(a.stderr, "Deleting cluster %s (%s) removes all its node pools and workloads.\n",
+			klaster.GetName(), clusterID)
+		fmt.Fprintf(a.stderr, "Type the name of the cluster to confirm: ")
+
+		answer, _ := bufio.NewReader(a.stdin).ReadString('\n')
+		if strings.TrimSpace(answer) != klaster.GetName() {
+			return fmt.Errorf("deletion not confirmed")
+		}
+	}
+
+	if err := a.client.DeleteCluster(ctx, clusterID); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(a.stderr, "Cluster %s deleted.\n", klaster.GetName())
+	return nil
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"text/tabwriter"
+	"time"
+)
+
+func runErrors(ctx context.Context, a *app, args []string) error {
+	clusterID, err := a.client.ResolveClusterID(ctx, args[0])
+	if err != nil {
+		return err
+	}
+
+	klaster, err := a.client.GetCluster(ctx, clusterID)
+	if err != nil {
+		return err
+	}
+
+	views := make([]*errorView, 0, len(klaster.GetErrors()))
+	for _, el := range klaster.GetErrors() {
+		views = append(views, newErrorView(el))
+	}
+
+	slices.SortFunc(views, func(a, b *errorView) int {
+		return b.CreatedAt.Compare(a.CreatedAt)
+	})
+
+	return a.print(views, func(w *tabwriter.Writer) {
+		row(w, "CREATED", "ID", "MESSAGE")
+		for _, el := range views {
+			row(w, el.CreatedAt.Local().Format(time.RFC3339), el.ID, el.Message)
+		}
+	})
+}
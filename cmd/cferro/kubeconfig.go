@@ -0,0 +1,204 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+func kubeconfigGetFlags(fs *flag.FlagSet, a *app) {
+	fs.BoolVar(&a.merge, "merge", false, "merge into the kubeconfig file and switch to the cluster context "+
+		"instead of printing it")
+	fs.StringVar(&a.kubeconfig, "kubeconfig", "", "kubeconfig file to merge into, defaults to the first "+
+		"file of KUBECONFIG or ~/.kube/config")
+}
+
+func runKubeconfigGet(ctx context.Context, a *app, args []string) error {
+	clusterID, err := a.client.ResolveClusterID(ctx, args[0])
+	if err != nil {
+		return err
+	}
+
+	kubeconfig, err := a.client.Kubeconfig(ctx, clusterID)
+	if err != nil {
+		return err
+	}
+
+	if !a.merge {
+		_, err := io.WriteString(a.stdout, kubeconfig)
+		return err
+	}
+
+	name := a.kubeconfig
+	if name == "" {
+		name, err = defaultKubeconfigPath()
+		if err != nil {
+			return err
+		}
+	}
+
+	existing, err := os.ReadFile(name)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	cluster, err := a.client.GetCluster(ctx, clusterID)
+	if err != nil {
+		return err
+	}
+
+	merged, currentContext, err := mergeKubeconfig(existing, []byte(kubeconfig), cluster.GetName())
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(name), 0o700); err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(name, merged, 0o600); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(a.stderr, "Merged %q into %s, current context is now %q.\n", args[0], name, currentContext)
+	return nil
+}
+
+func defaultKubeconfigPath() (string, error) {
+	if env := filepath.SplitList(os.Getenv("KUBECONFIG")); len(env) > 0 && env[0] != "" {
+		return env[0], nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(home, ".kube", "config"), nil
+}
+
+// kubeconfigLists are the lists of named entries of a kubeconfig.
+var kubeconfigLists = []string{"clusters", "contexts", "users"}
+
+// mergeKubeconfig adds the clusters, contexts and users of incoming to
+// existing, replacing the entries with the same names, and makes the current
+// context of incoming the current one. Other settings of existing are kept.
+//
+// The kubeconfigs of the service use the same names for every cluster, so the
+// entries of incoming are renamed after clusterName first, to keep the ones
+// of the clusters merged before.
+func mergeKubeconfig(existing, incoming []byte, clusterName string) ([]byte, string, error) {
+	var base, add map[string]any
+
+	if err := yaml.Unmarshal(existing, &base); err != nil {
+		return nil, "", fmt.Errorf("failed to parse the existing kubeconfig: %w", err)
+	}
+
+	if err := yaml.Unmarshal(incoming, &add); err != nil {
+		return nil, "", fmt.Errorf("failed to parse the cluster kubeconfig: %w", err)
+	}
+
+	renameKubeconfigEntries(add, clusterName)
+
+	if base == nil {
+		base = map[string]any{
+			"apiVersion":  "v1",
+			"kind":        "Config",
+			"preferences": map[string]any{},
+		}
+	}
+
+	for _, key := range kubeconfigLists {
+		entries, _ := base[key].([]any)
+
+		for _, el := range asList(add[key]) {
+			name := entryName(el)
+
+			i := 0
+			for ; i < len(entries); i++ {
+				if entryName(entries[i]) == name {
+					break
+				}
+			}
+
+			if i < len(entries) {
+				entries[i] = el
+			} else {
+				entries = append(entries, el)
+			}
+		}
+
+		base[key] = entries
+	}
+
+	currentContext, _ := add["current-context"].(string)
+	if currentContext != "" {
+		base["current-context"] = currentContext
+	}
+
+	out, err := yaml.Marshal(base)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return out, currentContext, nil
+}
+
+// renameKubeconfigEntries names the entries of kubeconfig after clusterName,
+// followed by their former name when a list has several of them, and updates
+// the references of the contexts and the current context.
+func renameKubeconfigEntries(kubeconfig map[string]any, clusterName string) {
+	renames := make(map[string]map[string]string, len(kubeconfigLists))
+
+	for _, key := range kubeconfigLists {
+		entries := asList(kubeconfig[key])
+		renames[key] = make(map[string]string, len(entries))
+
+		for _, el := range entries {
+			entry, ok := el.(map[string]any)
+			if !ok {
+				continue
+			}
+
+			name := clusterName
+			if len(entries) > 1 {
+				name += "-" + entryName(entry)
+			}
+
+			renames[key][entryName(entry)] = name
+			entry["name"] = name
+		}
+	}
+
+	for _, el := range asList(kubeconfig["contexts"]) {
+		entry, _ := el.(map[string]any)
+		spec, _ := entry["context"].(map[string]any)
+
+		for field, key := range map[string]string{"cluster": "clusters", "user": "users"} {
+			if name, ok := spec[field].(string); ok && renames[key][name] != "" {
+				spec[field] = renames[key][name]
+			}
+		}
+	}
+
+	if name, ok := kubeconfig["current-context"].(string); ok && renames["contexts"][name] != "" {
+		kubeconfig["current-context"] = renames["contexts"][name]
+	}
+}
+
+func asList(v any) []any {
+	list, _ := v.([]any)
+	return list
+}
+
+func entryName(v any) string {
+	entry, _ := v.(map[string]any)
+	name, _ := entry["name"].(string)
+	return name
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// testClusterKubeconfig is a kubeconfig like the service returns, with the
+// same names for every cluster.
+const testClusterKubeconfig = `
+apiVersion: v1
+kind: Config
+clusters:
+- name: kubernetes
+  cluster:
+    server: https://192.0.2.10:6443
+contexts:
+- name: kubernetes-admin@kubernetes
+  context:
+    cluster: kubernetes
+    user: kubernetes-admin
+users:
+- name: kubernetes-admin
+  user:
+    token: new-token
+current-context: kubernetes-admin@kubernetes
+`
+
+type testKubeconfig struct {
+	Clusters []struct {
+		Name    string `yaml:"name"`
+		Cluster struct {
+			Server string `yaml:"server"`
+		} `yaml:"cluster"`
+	} `yaml:"clusters"`
+	Contexts []struct {
+		Name    string `yaml:"name"`
+		Context struct {
+			Cluster string `yaml:"cluster"`
+			User    string `yaml:"user"`
+		} `yaml:"context"`
+	} `yaml:"contexts"`
+	Users []struct {
+		Name string `yaml:"name"`
+		User struct {
+			Token string `yaml:"token"`
+		} `yaml:"user"`
+	} `yaml:"users"`
+	CurrentContext string         `yaml:"current-context"`
+	Preferences    map[string]any `yaml:"preferences"`
+}
+
+func TestMergeKubeconfig(t *testing.T) {
+	existing := `
+apiVersion: v1
+kind: Config
+clusters:
+- name: dev
+  cluster:
+    server: https://192.0.2.20:6443
+- name: prod
+  cluster:
+    server: https://192.0.2.99:6443
+contexts:
+- name: dev
+  context:
+    cluster: dev
+    user: dev
+- name: prod
+  context:
+    cluster: prod
+    user: prod
+users:
+- name: dev
+  user:
+    token: dev-token
+- name: prod
+  user:
+    token: old-token
+current-context: dev
+preferences:
+  colors: true
+`
+
+	merged, currentContext, err := mergeKubeconfig([]byte(existing), []byte(testClusterKubeconfig), "prod")
+	if err != nil {
+		t.Fatalf("mergeKubeconfig() error = %v", err)
+	}
+
+	if currentContext != "prod" {
+		t.Errorf("current context = %q, want %q", currentContext, "prod")
+	}
+
+	var got testKubeconfig
+	if err := yaml.Unmarshal(merged, &got); err != nil {
+		t.Fatalf("failed to parse merged kubeconfig: %v", err)
+	}
+
+	if len(got.Clusters) != 2 || got.Clusters[0].Name != "dev" || got.Clusters[1].Cluster.Server != "https://192.0.2.10:6443" {
+		t.Errorf("clusters = %+v, want dev kept and prod replaced", got.Clusters)
+	}
+
+	if len(got.Contexts) != 2 || got.Contexts[1].Name != "prod" {
+		t.Errorf("contexts = %+v, want dev and prod", got.Contexts)
+	}
+
+	if len(got.Users) != 2 || got.Users[0].User.Token != "dev-token" || got.Users[1].User.Token != "new-token" {
+		t.Errorf("users = %+v, want dev kept and prod replaced", got.Users)
+	}
+
+	if got.CurrentContext != "prod" {
+		t.Errorf("current-context = %q, want %q", got.CurrentContext, "prod")
+	}
+
+	if got.Preferences["colors"] != true {
+		t.Errorf("preferences = %v, want them kept", got.Preferences)
+	}
+}
+
+func TestMergeKubeconfig_twoClusters(t *testing.T) {
+	merged, _, err := mergeKubeconfig(nil, []byte(testClusterKubeconfig), "dev")
+	if err != nil {
+		t.Fatalf("mergeKubeconfig() error = %v", err)
+	}
+
+	prod := strings.NewReplacer("192.0.2.10", "192.0.2.11", "new-token", "prod-token").Replace(testClusterKubeconfig)
+	merged, _, err = mergeKubeconfig(merged, []byte(prod), "prod")
+	if err != nil {
+		t.Fatalf("mergeKubeconfig() error = %v", err)
+	}
+
+	var got testKubeconfig
+	if err := yaml.Unmarshal(merged, &got); err != nil {
+		t.Fatalf("failed to parse merged kubeconfig: %v", err)
+	}
+
+	if len(got.Clusters) != 2 ||
+		got.Clusters[0].Name != "dev" || got.Clusters[0].Cluster.Server != "https://192.0.2.10:6443" ||
+		got.Clusters[1].Name != "prod" || got.Clusters[1].Cluster.Server != "https://192.0.2.11:6443" {
+		t.Errorf("clusters = %+v, want dev and prod", got.Clusters)
+	}
+
+	if len(got.Users) != 2 || got.Users[0].User.Token != "new-token" || got.Users[1].User.Token != "prod-token" {
+		t.Errorf("users = %+v, want the credentials of dev and prod", got.Users)
+	}
+
+	for _, el := range got.Contexts {
+		if el.Context.Cluster != el.Name || el.Context.User != el.Name {
+			t.Errorf("context %q = %+v, want it to reference its cluster and user", el.Name, el.Context)
+		}
+	}
+
+	if len(got.Contexts) != 2 || got.CurrentContext != "prod" {
+		t.Errorf("contexts = %+v, current-context = %q, want dev and prod, prod", got.Contexts, got.CurrentContext)
+	}
+}
+
+func TestMergeKubeconfig_empty(t *testing.T) {
+	merged, _, err := mergeKubeconfig(nil, []byte(testClusterKubeconfig), "prod")
+	if err != nil {
+		t.Fatalf("mergeKubeconfig() error = %v", err)
+	}
+
+	var got map[string]any
+	if err := yaml.Unmarshal(merged, &got); err != nil {
+		t.Fatalf("failed to parse merged kubeconfig: %v", err)
+	}
+
+	for _, key := range []string{"apiVersion", "kind", "clusters", "contexts", "users", "current-context"} {
+		if _, ok := got[key]; !ok {
+			t.Errorf("merged kubeconfig is missing %q", key)
+		}
+	}
+}
@@ -0,0 +1,248 @@
+// Command cferro performs day-2 operations on CloudFerro Managed Kubernetes
+// clusters: listing clusters and node pools, scaling node pools, fetching
+// kubeconfigs and showing why a cluster is in the Error state.
+//
+// It is configured with the same environment variables as the Terraform
+// provider: CLOUDFERRO_TOKEN, CLOUDFERRO_REGION or CLOUDFERRO_HOST, and
+// CLOUDFERRO_CERT.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"time"
+
+	"github.com/cloudferro/terraform-provider-cloudferro/client"
+	"github.com/cloudferro/terraform-provider-cloudferro/internal/config"
+	"google.golang.org/grpc/status"
+)
+
+// command is a subcommand, such as "clusters list".
+type command struct {
+	args  string
+	nargs int
+	help  string
+	run   func(ctx context.Context, a *app, args []string) error
+	// flags registers the flags of the command, in addition to the common
+	// ones.
+	flags func(fs *flag.FlagSet, a *app)
+}
+
+var commands = map[string]command{
+	"clusters list": {
+		help: "list clusters",
+		run:  runClustersList,
+	},
+	"clusters get": {
+		args:  "<cluster>",
+		nargs: 1,
+		help:  "show a cluster",
+		run:   runClustersGet,
+	},
+	"clusters delete": {
+		args:  "<cluster>",
+		nargs: 1,
+		help:  "delete a cluster and wait until it is gone",
+		run:   runClustersDelete,
+		flags: clustersDeleteFlags,
+	},
+	"nodepools list": {
+		args:  "<cluster>",
+		nargs: 1,
+		help:  "list node pools of a cluster",
+		run:   runNodePoolsList,
+	},
+	"nodepools scale": {
+		args:  "<cluster> <node pool>",
+		nargs: 2,
+		help:  "change the size of a node pool and wait until it is running",
+		run:   runNodePoolsScale,
+		flags: nodePoolsScaleFlags,
+	},
+	"kubeconfig get": {
+		args:  "<cluster>",
+		nargs: 1,
+		help:  "print the kubeconfig of a cluster, or merge it into a kubeconfig file",
+		run:   runKubeconfigGet,
+		flags: kubeconfigGetFlags,
+	},
+	"errors": {
+		args:  "<cluster>",
+		nargs: 1,
+		help:  "list errors reported for a cluster, newest first",
+		run:   runErrors,
+	},
+}
+
+// app holds the state shared by the commands.
+type app struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+
+	output  string
+	timeout time.Duration
+
+	// flag values of the commands
+	yes        bool
+	size       int
+	sizeMin    int
+	sizeMax    int
+	merge      bool
+	kubeconfig string
+
+	client *client.Client
+}
+
+func main() {
+	a := &app{
+		stdin:  os.Stdin,
+		stdout: os.Stdout,
+		stderr: os.Stderr,
+	}
+
+	os.Exit(a.main(os.Args[1:]))
+}
+
+func (a *app) main(args []string) int {
+	name, rest := commandName(args)
+	cmd, ok := commands[name]
+	if !ok {
+		a.usage()
+		return 2
+	}
+
+	fs := flag.NewFlagSet("cferro "+name, flag.ContinueOnError)
+	fs.SetOutput(a.stderr)
+	fs.StringVar(&a.output, "o", "table", "output format, table or json")
+	fs.DurationVar(&a.timeout, "timeout", 30*time.Minute, "how long to wait for the operation")
+	if cmd.flags != nil {
+		cmd.flags(fs, a)
+	}
+	fs.Usage = func() {
+		fmt.Fprintf(a.stderr, "Usage: cferro %s [flags] %s\n\n%s\n\nFlags:\n", name, cmd.args, cmd.help)
+		fs.PrintDefaults()
+	}
+
+	args, err := parseInterspersed(fs, rest)
+	if err != nil {
+		return 2
+	}
+
+	if a.output != "table" && a.output != "json" {
+		fmt.Fprintf(a.stderr, "invalid output format %q, expected table or json\n", a.output)
+		return 2
+	}
+
+	if len(args) != cmd.nargs {
+		fs.Usage()
+		return 2
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	ctx, cancel := context.WithTimeout(ctx, a.timeout)
+	defer cancel()
+
+	if err := a.connect(); err != nil {
+		fmt.Fprintf(a.stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	if err := cmd.run(ctx, a, args); err != nil {
+		fmt.Fprintf(a.stderr, "Error: %s\n", errorMessage(err))
+		return 1
+	}
+
+	return 0
+}
+
+// commandName splits args into the name of the command and its arguments.
+func commandName(args []string) (string, []string) {
+	if len(args) >= 1 {
+		if _, ok := commands[args[0]]; ok {
+			return args[0], args[1:]
+		}
+	}
+
+	if len(args) >= 2 {
+		return args[0] + " " + args[1], args[2:]
+	}
+
+	return "", nil
+}
+
+// parseInterspersed parses flags placed before, between and after the
+// positional arguments, which it returns.
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+func (a *app) usage() {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintf(a.stderr, "Usage: cferro <command> [flags] [arguments]\n\nCommands:\n")
+	for _, name := range names {
+		fmt.Fprintf(a.stderr, "  %-40s %s\n", name+" "+commands[name].args, commands[name].help)
+	}
+	fmt.Fprintf(a.stderr, "\nThe connection is configured with the %s, %s or %s, and %s environment variables.\n",
+		config.EnvToken, config.EnvRegion, config.EnvHost, config.EnvCert)
+}
+
+func (a *app) connect() error {
+	cfg := config.FromEnv()
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	if err := config.SystemCertsError(); err != nil {
+		fmt.Fprintf(a.stderr, "Warning: failed to load the system certificates: %v\n", err)
+	}
+
+	conn, err := cfg.Connect()
+	if err != nil {
+		return err
+	}
+
+	a.client = client.New(conn, client.Options{})
+	return nil
+}
+
+// errorMessage returns the message of err without the gRPC status prefix.
+func errorMessage(err error) string {
+	var opErr *client.OperationError
+	if errors.As(err, &opErr) {
+		return opErr.Error()
+	}
+
+	if st, ok := status.FromError(err); ok {
+		return fmt.Sprintf("%s (%s)", st.Message(), st.Code())
+	}
+
+	return err.Error()
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/cloudferro/terraform-provider-cloudferro/client"
+)
+
+func runNodePoolsList(ctx context.Context, a *app, args []string) error {
+	clusterID, err := a.client.ResolveClusterID(ctx, args[0])
+	if err != nil {
+		return err
+	}
+
+	nodePools, err := a.client.ListNodePools(ctx, clusterID)
+	if err != nil {
+		return err
+	}
+
+	views := make([]nodePoolView, 0, len(nodePools))
+	for _, el := range nodePools {
+		views = append(views, newNodePoolView(el))
+	}
+
+	slices.SortFunc(views, func(a, b nodePoolView) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return a.print(views, func(w *tabwriter.Writer) {
+		row(w, "ID", "NAME", "STATUS", "FLAVOR", "SIZE", "NODES")
+		for _, el := range views {
+			row(w, el.ID, el.Name, el.Status, el.Flavor, el.sizeString(), len(el.Nodes))
+		}
+	})
+}
+
+func nodePoolsScaleFlags(fs *flag.FlagSet, a *app) {
+	fs.IntVar(&a.size, "size", -1, "number of nodes of a static node pool")
+	fs.IntVar(&a.sizeMin, "min", -1, "minimum number of nodes, turns autoscaling on")
+	fs.IntVar(&a.sizeMax, "max", -1, "maximum number of nodes, turns autoscaling on")
+}
+
+func runNodePoolsScale(ctx context.Context, a *app, args []string) error {
+	var spec client.NodePoolSpec
+
+	switch {
+	case a.size >= 0 && a.sizeMin < 0 && a.sizeMax < 0:
+		size := int32(a.size) //nolint:gosec // validated by the service
+		spec.Size = &size
+	case a.size < 0 && a.sizeMin >= 0 && a.sizeMax >= a.sizeMin:
+		sizeMin, sizeMax := int32(a.sizeMin), int32(a.sizeMax) //nolint:gosec // validated by the service
+		spec.Autoscale = true
+		spec.SizeMin = &sizeMin
+		spec.SizeMax = &sizeMax
+	default:
+		return fmt.Errorf("set either -size, or -min and -max with min not greater than max")
+	}
+
+	clusterID, err := a.client.ResolveClusterID(ctx, args[0])
+	if err != nil {
+		return err
+	}
+
+	nodePoolID, err := a.client.ResolveNodePoolID(ctx, clusterID, args[1])
+	if err != nil {
+		return err
+	}
+
+	nodePool, err := a.client.UpdateNodePool(ctx, clusterID, nodePoolID, spec, nil)
+	if err != nil {
+		return err
+	}
+
+	view := newNodePoolView(nodePool)
+	return a.print(view, func(w *tabwriter.Writer) {
+		row(w, "ID", "NAME", "STATUS", "FLAVOR", "SIZE", "NODES")
+		row(w, view.ID, view.Name, view.Status, view.Flavor, view.sizeString(), len(view.Nodes))
+	})
+}
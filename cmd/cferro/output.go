@@ -0,0 +1,144 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"gitlab.cloudferro.com/k8s/api/cluster/v1"
+	cferror "gitlab.cloudferro.com/k8s/api/error/v1"
+	"gitlab.cloudferro.com/k8s/api/nodepool/v1"
+)
+
+// The views are the JSON output of the commands. They are decoupled from the
+// API messages, so the output stays stable when the API changes.
+
+type clusterView struct {
+	ID                 string     `json:"id"`
+	Name               string     `json:"name"`
+	Status             string     `json:"status"`
+	Version            string     `json:"version"`
+	ControlPlaneSize   int32      `json:"control_plane_size"`
+	ControlPlaneFlavor string     `json:"control_plane_flavor"`
+	RouterIP           string     `json:"router_ip,omitempty"`
+	OpenstackProjectID string     `json:"openstack_project_id,omitempty"`
+	LatestError        *errorView `json:"latest_error,omitempty"`
+}
+
+type nodeView struct {
+	Name      string `json:"name"`
+	ServerID  string `json:"server_id"`
+	PrivateIP string `json:"private_ip"`
+	Status    string `json:"status"`
+}
+
+type nodePoolView struct {
+	ID        string     `json:"id"`
+	Name      string     `json:"name"`
+	Status    string     `json:"status"`
+	Flavor    string     `json:"flavor"`
+	Autoscale bool       `json:"autoscale"`
+	Size      *int32     `json:"size,omitempty"`
+	SizeMin   *int32     `json:"size_min,omitempty"`
+	SizeMax   *int32     `json:"size_max,omitempty"`
+	Nodes     []nodeView `json:"nodes"`
+}
+
+type errorView struct {
+	ID        string    `json:"id"`
+	Message   string    `json:"message"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func newClusterView(klaster *cluster.Cluster) clusterView {
+	return clusterView{
+		ID:                 klaster.GetId(),
+		Name:               klaster.GetName(),
+		Status:             klaster.GetStatus(),
+		Version:            klaster.GetVersion().GetVersion(),
+		ControlPlaneSize:   klaster.GetControlPlane().GetCustom().GetSize(),
+		ControlPlaneFlavor: klaster.GetControlPlane().GetCustom().GetMachineSpec().GetName(),
+		RouterIP:           klaster.GetRouterIp(),
+		OpenstackProjectID: klaster.GetMetadata().GetOpenstackProjectId(),
+	}
+}
+
+func newNodePoolView(nodePool *nodepool.NodePool) nodePoolView {
+	view := nodePoolView{
+		ID:        nodePool.GetId(),
+		Name:      nodePool.GetName(),
+		Status:    nodePool.GetStatus(),
+		Flavor:    nodePool.GetMachineSpec().GetName(),
+		Autoscale: nodePool.GetAutoscale(),
+		Size:      nodePool.Size,
+		SizeMin:   nodePool.SizeMin,
+		SizeMax:   nodePool.SizeMax,
+		Nodes:     []nodeView{},
+	}
+
+	for _, el := range nodePool.GetNodes() {
+		view.Nodes = append(view.Nodes, nodeView{
+			Name:      el.GetName(),
+			ServerID:  el.GetServerId(),
+			PrivateIP: el.GetPrivateIp(),
+			Status:    el.GetStatus(),
+		})
+	}
+
+	return view
+}
+
+func newErrorView(err *cferror.Error) *errorView {
+	if err == nil {
+		return nil
+	}
+
+	return &errorView{
+		ID:        err.GetId(),
+		Message:   err.GetMsg(),
+		CreatedAt: err.GetCreatedAt().AsTime(),
+	}
+}
+
+// sizeString describes the size of the node pool for the table output.
+func (v nodePoolView) sizeString() string {
+	if v.Autoscale {
+		return fmt.Sprintf("%s-%s (autoscale)", int32String(v.SizeMin), int32String(v.SizeMax))
+	}
+
+	return int32String(v.Size)
+}
+
+func int32String(v *int32) string {
+	if v == nil {
+		return "-"
+	}
+
+	return fmt.Sprint(*v)
+}
+
+// print writes v as indented JSON or, for the table output, calls table with
+// a tab separated writer.
+func (a *app) print(v any, table func(w *tabwriter.Writer)) error {
+	if a.output == "json" {
+		enc := json.NewEncoder(a.stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	}
+
+	w := tabwriter.NewWriter(a.stdout, 0, 4, 2, ' ', 0)
+	table(w)
+	return w.Flush()
+}
+
+// row writes the cells as a row of the table.
+func row(w *tabwriter.Writer, cells ...any) {
+	s := make([]string, len(cells))
+	for i, el := range cells {
+		s[i] = fmt.Sprint(el)
+	}
+
+	fmt.Fprintln(w, strings.Join(s, "\t"))
+}
@@ -0,0 +1,143 @@
+// Command cloudferro-emulator runs a local emulator of the CloudFerro Managed
+// Kubernetes API, so Terraform configurations using the provider can be
+// applied without a CloudFerro account.
+//
+// The emulator serves the gRPC API over TLS with a self-signed certificate
+// written to -cert-file. Point the provider at it with:
+//
+//	provider "cloudferro" {
+//	  host        = "127.0.0.1:8443"
+//	  token       = "emulator"
+//	  server_cert = "emulator-cert.pem"
+//	}
+//
+// Clusters and node pools are kept in -state-file between runs. Errors are
+// injected through the HTTP control endpoint listening on -control-addr, for
+// example:
+//
+//	curl -X POST localhost:8444/errors -d '{"method":"CreateNodePool","code":"RESOURCE_EXHAUSTED","message":"quota exceeded"}'
+//	curl -X POST localhost:8444/error-states -d '{"method":"CreateCluster","message":"not enough floating ips"}'
+//	curl -X POST localhost:8444/reset
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"flag"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/cloudferro/terraform-provider-cloudferro/internal/fakeapi"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+func main() {
+	var (
+		addr        string
+		controlAddr string
+		stateFile   string
+		certFile    string
+		hostnames   string
+		versions    string
+		specs       string
+		opts        fakeapi.Options
+	)
+
+	flag.StringVar(&addr, "addr", "127.0.0.1:8443", "address the gRPC API listens on")
+	flag.StringVar(&controlAddr, "control-addr", "127.0.0.1:8444", "address the HTTP control endpoint listens on")
+	flag.StringVar(&stateFile, "state-file", "emulator-state.json", "file the state is persisted to, empty to keep it in memory")
+	flag.StringVar(&certFile, "cert-file", "emulator-cert.pem", "file the certificate of the API is written to, "+
+		"for the server_cert provider attribute")
+	flag.StringVar(&hostnames, "hostnames", "localhost,127.0.0.1", "comma separated host names and addresses "+
+		"the certificate is issued for")
+	flag.StringVar(&opts.Token, "token", "", "token required from clients, any token is accepted when empty")
+	flag.DurationVar(&opts.TransitionDelay, "transition-delay", defaultTransitionDelay,
+		"how long clusters and node pools stay in the Creating, Updating and Deleting states")
+	flag.DurationVar(&opts.Latency, "latency", 0, "delay added to every call")
+	flag.StringVar(&versions, "kubernetes-versions", strings.Join(fakeapi.DefaultKubernetesVersions, ","),
+		"comma separated Kubernetes versions offered")
+	flag.StringVar(&specs, "machine-specs", strings.Join(fakeapi.DefaultMachineSpecs, ","),
+		"comma separated machine specs (flavors) offered")
+	flag.Parse()
+
+	opts.KubernetesVersions = strings.Split(versions, ",")
+	opts.MachineSpecs = strings.Split(specs, ",")
+
+	srv := fakeapi.New(opts)
+	if stateFile != "" {
+		if err := srv.LoadFile(stateFile); err != nil {
+			log.Fatalf("failed to load state: %v", err)
+		}
+	}
+
+	cert, certPEM, err := fakeapi.SelfSignedCertificate(strings.Split(hostnames, ",")...)
+	if err != nil {
+		log.Fatalf("failed to generate certificate: %v", err)
+	}
+
+	if err := os.WriteFile(certFile, certPEM, 0o644); err != nil { //nolint:gosec // the certificate is public
+		log.Fatalf("failed to write certificate: %v", err)
+	}
+
+	save := func() {
+		if stateFile == "" {
+			return
+		}
+
+		if err := srv.SaveFile(stateFile); err != nil {
+			log.Printf("failed to save state: %v", err)
+		}
+	}
+
+	g := grpc.NewServer(append(
+		srv.ServerOptions(),
+		grpc.ChainUnaryInterceptor(persistInterceptor(save)),
+		grpc.Creds(credentials.NewTLS(&tls.Config{
+			Certificates: []tls.Certificate{cert},
+			MinVersion:   tls.VersionTLS12,
+		})),
+	)...)
+	srv.Register(g)
+
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		log.Fatalf("failed to listen: %v", err)
+	}
+
+	control := &http.Server{
+		Addr:              controlAddr,
+		Handler:           persistHandler(srv.ControlHandler(), save),
+		ReadHeaderTimeout: defaultReadHeaderTimeout,
+	}
+
+	go func() {
+		if err := control.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("failed to serve control endpoint: %v", err)
+		}
+	}()
+
+	go func() {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		<-ctx.Done()
+
+		_ = control.Close()
+		g.GracefulStop()
+	}()
+
+	log.Printf("serving the API on %s, certificate written to %s", lis.Addr(), certFile)
+	log.Printf("serving the control endpoint on %s", controlAddr)
+
+	if err := g.Serve(lis); err != nil {
+		log.Fatalf("failed to serve: %v", err)
+	}
+
+	save()
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+const (
+	defaultTransitionDelay   = 5 * time.Second
+	defaultReadHeaderTimeout = 10 * time.Second
+)
+
+// mutatingPrefixes are prefixes of the methods which change the state.
+var mutatingPrefixes = []string{"Create", "Update", "Delete"}
+
+// persistInterceptor saves the state after every call which changes it.
+// Operations in progress are saved with the time they finish at, so read calls
+// need no saving.
+func persistInterceptor(save func()) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		resp, err := handler(ctx, req)
+
+		method := info.FullMethod[strings.LastIndex(info.FullMethod, "/")+1:]
+		for _, el := range mutatingPrefixes {
+			if err == nil && strings.HasPrefix(method, el) {
+				save()
+				break
+			}
+		}
+
+		return resp, err
+	}
+}
+
+// persistHandler saves the state after every control request which may change
+// it.
+func persistHandler(next http.Handler, save func()) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r)
+
+		if r.Method == http.MethodPost {
+			save()
+		}
+	})
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestPersistInterceptor(t *testing.T) {
+	tests := []struct {
+		name     string
+		method   string
+		err      error
+		wantSave bool
+	}{
+		{name: "create", method: "/clusterservice.v1.Cluster/CreateCluster", wantSave: true},
+		{name: "delete", method: "/nodepoolservice.v1.NodePool/DeleteNodePool", wantSave: true},
+		{name: "read", method: "/clusterservice.v1.Cluster/GetCluster"},
+		{name: "failed", method: "/clusterservice.v1.Cluster/UpdateCluster", err: status.Error(codes.Internal, "")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			saved := false
+			interceptor := persistInterceptor(func() { saved = true })
+
+			_, err := interceptor(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: tt.method},
+				func(context.Context, any) (any, error) { return nil, tt.err })
+			if !errors.Is(err, tt.err) {
+				t.Errorf("persistInterceptor() error = %v, want %v", err, tt.err)
+			}
+			if saved != tt.wantSave {
+				t.Errorf("persistInterceptor() saved = %t, want %t", saved, tt.wantSave)
+			}
+		})
+	}
+}
+
+func TestPersistHandler(t *testing.T) {
+	for method, wantSave := range map[string]bool{http.MethodPost: true, http.MethodGet: false} {
+		saved := false
+		handler := persistHandler(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}), func() { saved = true })
+
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(method, "/reset", nil))
+		if saved != wantSave {
+			t.Errorf("persistHandler() saved after %s = %t, want %t", method, saved, wantSave)
+		}
+	}
+}
@@ -0,0 +1,20 @@
+resource "cloudferro_kubernetes_cluster_v1" "cluster" {
+  control_plane = {
+    flavor = "eo2a.2xlarge"
+    size   = 3
+  }
+  name             = "my cluster"
+  version          = "1.30.10"
+  store_kubeconfig = false
+}
+
+ephemeral "cloudferro_kubernetes_cluster_credentials_v1" "cluster" {
+  cluster_id = cloudferro_kubernetes_cluster_v1.cluster.id
+}
+
+provider "kubernetes" {
+  host                   = ephemeral.cloudferro_kubernetes_cluster_credentials_v1.cluster.host
+  cluster_ca_certificate = ephemeral.cloudferro_kubernetes_cluster_credentials_v1.cluster.cluster_ca_certificate
+  client_certificate     = ephemeral.cloudferro_kubernetes_cluster_credentials_v1.cluster.client_certificate
+  client_key             = ephemeral.cloudferro_kubernetes_cluster_credentials_v1.cluster.client_key
+}
@@ -0,0 +1,10 @@
+locals {
+  credentials = provider::cloudferro::kubeconfig_decode(cloudferro_kubernetes_cluster_v1.cluster.kubeconfig)
+}
+
+provider "kubernetes" {
+  host                   = local.credentials.host
+  cluster_ca_certificate = local.credentials.cluster_ca_certificate
+  client_certificate     = local.credentials.client_certificate
+  client_key             = local.credentials.client_key
+}
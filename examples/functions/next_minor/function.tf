@@ -0,0 +1,4 @@
+# 1.31.0
+output "next_minor" {
+  value = provider::cloudferro::next_minor("1.30.10")
+}
@@ -0,0 +1,3 @@
+output "needs_upgrade" {
+  value = provider::cloudferro::version_compare(cloudferro_kubernetes_cluster_v1.cluster.version, "1.31.6") < 0
+}
@@ -1,3 +1,4 @@
-
-
 terraform import cloudferro_kubernetes_cluster_v1.example cluster_id
+
+# or by the cluster name
+terraform import cloudferro_kubernetes_cluster_v1.example cluster_name
@@ -1,2 +1,4 @@
-
 terraform import cloudferro_kubernetes_node_pool_v1.example cluster_id/node_pool_id
+
+# or by the cluster and node pool names
+terraform import cloudferro_kubernetes_node_pool_v1.example cluster_name/node_pool_name
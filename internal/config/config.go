@@ -0,0 +1,435 @@
+// Package config resolves the settings used to connect to the CloudFerro
+// Managed Kubernetes API. It is shared by the provider and the cferro command,
+// so both honour the same environment variables.
+package config
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cloudferro/terraform-provider-cloudferro/internal/keystone"
+	"github.com/cloudferro/terraform-provider-cloudferro/internal/rest"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+)
+
+// Environment variables read by FromEnv.
+const (
+	EnvHost   = "CLOUDFERRO_HOST"
+	EnvToken  = "CLOUDFERRO_TOKEN"
+	EnvRegion = "CLOUDFERRO_REGION"
+	EnvCert   = "CLOUDFERRO_CERT"
+
+	EnvCertPEM       = "CLOUDFERRO_CERT_PEM"
+	EnvClientCert    = "CLOUDFERRO_CLIENT_CERT"
+	EnvClientKey     = "CLOUDFERRO_CLIENT_KEY"
+	EnvTLSServerName = "CLOUDFERRO_TLS_SERVER_NAME"
+	EnvInsecure      = "CLOUDFERRO_INSECURE"
+
+	// The lower case proxy variables are read when the upper case ones are
+	// not set, like Go's HTTP client does.
+	EnvHTTPSProxy   = "HTTPS_PROXY"
+	EnvHTTPSProxyLC = "https_proxy"
+	EnvNoProxy      = "NO_PROXY"
+	EnvNoProxyLC    = "no_proxy"
+
+	EnvTransport = "CLOUDFERRO_TRANSPORT"
+	EnvReadOnly  = "CLOUDFERRO_READ_ONLY"
+
+	EnvTokenFile = "CLOUDFERRO_TOKEN_FILE"
+	// EnvTokenCommand holds the command and its arguments, quoted like in a
+	// shell when they contain spaces.
+	EnvTokenCommand = "CLOUDFERRO_TOKEN_COMMAND"
+
+	// Keystone settings use the names of the OpenStack clients.
+	EnvAuthURL                     = "OS_AUTH_URL"
+	EnvApplicationCredentialID     = "OS_APPLICATION_CREDENTIAL_ID"
+	EnvApplicationCredentialSecret = "OS_APPLICATION_CREDENTIAL_SECRET"
+	EnvUsername                    = "OS_USERNAME"
+	EnvPassword                    = "OS_PASSWORD"
+	EnvUserDomainName              = "OS_USER_DOMAIN_NAME"
+	EnvProjectID                   = "OS_PROJECT_ID"
+	EnvProjectName                 = "OS_PROJECT_NAME"
+	EnvProjectDomainName           = "OS_PROJECT_DOMAIN_NAME"
+)
+
+// Transports the service is reached with.
+const (
+	TransportGRPC = "grpc"
+	// TransportHTTP sends the calls as HTTPS/JSON requests to the REST
+	// gateway, over HTTP/1.1.
+	TransportHTTP = "http"
+)
+
+var (
+	hostPrefix = "managed-kubernetes"
+	hostSuffix = "cloudferro.com"
+)
+
+var (
+	ErrMissingToken     = errors.New("missing API token or Keystone credentials")
+	ErrMissingEndpoint  = errors.New("either a region or a host must be set")
+	ErrHostAndRegion    = errors.New("region and host are mutually exclusive")
+	ErrTokenSources     = errors.New("token, token file and token command are mutually exclusive")
+	ErrInvalidTransport = errors.New("transport must be grpc or http")
+	// ErrInvalidTokenCommand is returned by Validate when the token command
+	// set in the environment cannot be split into arguments.
+	ErrInvalidTokenCommand = errors.New("invalid token command")
+)
+
+// Config holds the connection settings.
+type Config struct {
+	// Host is the address of the service, "host:port" or "host" if the port
+	// is 443. Mutually exclusive with Region.
+	Host string
+	// Region is the region of the public service, the host is derived from
+	// it.
+	Region string
+	// Token is a static API token. It takes precedence over Keystone.
+	Token string
+	// TokenFile is the path of a file holding the API token. It is read
+	// again whenever it changes. Mutually exclusive with Token.
+	TokenFile string
+	// TokenCommand is a credential helper and its arguments. It prints the
+	// API token, or an ExecCredential object like the kubectl exec plugins
+	// do. Mutually exclusive with Token and TokenFile.
+	TokenCommand []string
+	// tokenCommandErr is the error splitting the token command set in the
+	// environment, reported by Validate.
+	tokenCommandErr error
+	// Keystone credentials are used to obtain tokens when no API token is
+	// set.
+	Keystone keystone.Credentials
+	// ServerCert is the path of a PEM-encoded certificate trusted in
+	// addition to the system ones.
+	ServerCert string
+	// ServerCertPEM is the content of such a certificate. Mutually exclusive
+	// with ServerCert.
+	ServerCertPEM string
+	// ClientCert and ClientKey are the path or PEM-encoded content of the
+	// certificate and key presented to the service.
+	ClientCert string
+	ClientKey  string
+	// TLSServerName overrides the name the server certificate is verified
+	// against, and the authority sent to the service.
+	TLSServerName string
+	// Insecure turns off the verification of the server certificate.
+	Insecure bool
+	// ProxyURL is the HTTP CONNECT proxy to connect through, credentials
+	// given in the URL are sent with basic authentication.
+	ProxyURL string
+	// NoProxy lists the hosts, domains and networks to connect to directly,
+	// in the format of the NO_PROXY variable.
+	NoProxy string
+	// Transport is TransportGRPC, the default when empty, or TransportHTTP.
+	Transport string
+	// UserAgent identifies the program making the calls. It is sent as the
+	// user agent and the x-cloudferro-client metadata.
+	UserAgent string
+	// ReadOnly fails the calls which create, update or delete objects with
+	// ErrReadOnly, without sending them.
+	ReadOnly bool
+}
+
+// FromEnv returns the settings set in the environment.
+func FromEnv() Config {
+	tokenCommand, tokenCommandErr := splitCommand(os.Getenv(EnvTokenCommand))
+
+	return Config{
+		Host:            os.Getenv(EnvHost),
+		Region:          os.Getenv(EnvRegion),
+		Token:           os.Getenv(EnvToken),
+		TokenFile:       os.Getenv(EnvTokenFile),
+		TokenCommand:    tokenCommand,
+		tokenCommandErr: tokenCommandErr,
+		ServerCert:      os.Getenv(EnvCert),
+		ServerCertPEM:   os.Getenv(EnvCertPEM),
+		ClientCert:      os.Getenv(EnvClientCert),
+		ClientKey:       os.Getenv(EnvClientKey),
+		TLSServerName:   os.Getenv(EnvTLSServerName),
+		Insecure:        envBool(EnvInsecure),
+		ProxyURL:        proxyFromEnv(EnvHTTPSProxy, EnvHTTPSProxyLC),
+		NoProxy:         proxyFromEnv(EnvNoProxy, EnvNoProxyLC),
+		Transport:       os.Getenv(EnvTransport),
+		ReadOnly:        envBool(EnvReadOnly),
+		Keystone: keystone.Credentials{
+			AuthURL:                     os.Getenv(EnvAuthURL),
+			ApplicationCredentialID:     os.Getenv(EnvApplicationCredentialID),
+			ApplicationCredentialSecret: os.Getenv(EnvApplicationCredentialSecret),
+			Username:                    os.Getenv(EnvUsername),
+			Password:                    os.Getenv(EnvPassword),
+			UserDomainName:              os.Getenv(EnvUserDomainName),
+			ProjectID:                   os.Getenv(EnvProjectID),
+			ProjectName:                 os.Getenv(EnvProjectName),
+			ProjectDomainName:           os.Getenv(EnvProjectDomainName),
+		},
+	}
+}
+
+// envBool reports whether the variable is set to a true value, such as "1"
+// or "true".
+func envBool(name string) bool {
+	v, _ := strconv.ParseBool(os.Getenv(name))
+	return v
+}
+
+// SetTokenSources replaces the token sources, including the ones read from
+// the environment.
+func (c *Config) SetTokenSources(token, tokenFile string, tokenCommand []string) {
+	c.Token, c.TokenFile, c.TokenCommand = token, tokenFile, tokenCommand
+	c.tokenCommandErr = nil
+}
+
+// HostForRegion returns the address of the public service in region.
+func HostForRegion(region string) string {
+	return fmt.Sprintf("%s.%s.%s", hostPrefix, region, hostSuffix)
+}
+
+// Validate reports missing or conflicting settings.
+func (c Config) Validate() error {
+	var errs []error
+
+	switch {
+	case c.tokenSources() > 1:
+		errs = append(errs, fmt.Errorf("%w: set only one of %s, %s and %s", ErrTokenSources,
+			EnvToken, EnvTokenFile, EnvTokenCommand))
+	case c.tokenCommandErr != nil:
+		errs = append(errs, fmt.Errorf("%s: %w", EnvTokenCommand, c.tokenCommandErr))
+	case c.tokenSources() == 1:
+	case c.Keystone.IsSet():
+		if err := c.Keystone.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("invalid Keystone credentials: %w", err))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("%w: set %s, %s or %s, or %s and %s or %s and %s", ErrMissingToken,
+			EnvToken, EnvTokenFile, EnvTokenCommand,
+			EnvAuthURL, EnvApplicationCredentialID, EnvUsername, EnvPassword))
+	}
+
+	switch {
+	case c.Host == "" && c.Region == "":
+		errs = append(errs, fmt.Errorf("%w: set %s or %s", ErrMissingEndpoint, EnvRegion, EnvHost))
+	case c.Host != "" && c.Region != "":
+		errs = append(errs, fmt.Errorf("%w: unset %s or %s", ErrHostAndRegion, EnvRegion, EnvHost))
+	}
+
+	if err := c.validateTLS(); err != nil {
+		errs = append(errs, err)
+	}
+
+	if err := c.validateProxy(); err != nil {
+		errs = append(errs, err)
+	}
+
+	if c.Transport != "" && c.Transport != TransportGRPC && c.Transport != TransportHTTP {
+		errs = append(errs, fmt.Errorf("%w, got %q", ErrInvalidTransport, c.Transport))
+	}
+
+	return errors.Join(errs...)
+}
+
+func (c Config) tokenSources() int {
+	n := 0
+	for _, set := range []bool{c.Token != "", c.TokenFile != "", len(c.TokenCommand) > 0 || c.tokenCommandErr != nil} {
+		if set {
+			n++
+		}
+	}
+
+	return n
+}
+
+// Endpoint returns the address to connect to.
+func (c Config) Endpoint() string {
+	if c.Host != "" {
+		return c.Host
+	}
+
+	return HostForRegion(c.Region)
+}
+
+// Conn is a connection to the service over either transport.
+type Conn interface {
+	grpc.ClientConnInterface
+	Close() error
+}
+
+// Connect creates a connection to the service over the configured
+// transport. The interceptors run around every call of both transports.
+func (c Config) Connect(interceptors ...grpc.UnaryClientInterceptor) (Conn, error) {
+	if c.ReadOnly {
+		interceptors = append([]grpc.UnaryClientInterceptor{readOnly}, interceptors...)
+	}
+
+	if c.UserAgent != "" {
+		interceptors = append(interceptors, clientMetadata(c.UserAgent))
+	}
+
+	if c.Transport == TransportHTTP {
+		return c.dialHTTP(interceptors...)
+	}
+
+	return c.Dial(grpc.WithChainUnaryInterceptor(interceptors...))
+}
+
+// dialHTTP creates a connection to the REST gateway of the service.
+func (c Config) dialHTTP(interceptors ...grpc.UnaryClientInterceptor) (*rest.Conn, error) {
+	tlsConfig, err := c.TLSConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.validateProxy(); err != nil {
+		return nil, err
+	}
+
+	// HTTP/2 is what the networks this transport is meant for break
+	tlsConfig.NextProtos = []string{"http/1.1"}
+
+	httpClient := c.httpClient()
+	transport := httpClient.Transport.(*http.Transport)
+	transport.TLSClientConfig = tlsConfig
+	transport.ForceAttemptHTTP2 = false
+	transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	httpClient.Transport = tracedTransport{Transport: otelhttp.NewTransport(transport), base: transport}
+
+	perRPC, source := c.perRPCCredentials()
+	opts := rest.Options{
+		Host:         c.TLSServerName,
+		UserAgent:    c.UserAgent,
+		Credentials:  perRPC,
+		Interceptors: interceptors,
+	}
+	if source != nil {
+		opts.Invalidate = source.Invalidate
+	}
+
+	return rest.New("https://"+c.Endpoint(), httpClient, opts)
+}
+
+// ClientMetadataKey is the metadata identifying the program making a call.
+const ClientMetadataKey = "x-cloudferro-client"
+
+// clientMetadata returns a unary interceptor sending the client metadata.
+func clientMetadata(client string) grpc.UnaryClientInterceptor {
+	return func(
+		ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker,
+		opts ...grpc.CallOption,
+	) error {
+		ctx = metadata.AppendToOutgoingContext(ctx, ClientMetadataKey, client)
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+// tracedTransport traces the requests sent by the base transport, through
+// which the idle connections are still closed.
+type tracedTransport struct {
+	*otelhttp.Transport
+	base *http.Transport
+}
+
+func (t tracedTransport) CloseIdleConnections() {
+	t.base.CloseIdleConnections()
+}
+
+// Dial creates a gRPC client connection to the service. The connection is
+// established lazily, on the first call.
+func (c Config) Dial(opts ...grpc.DialOption) (*grpc.ClientConn, error) {
+	tlsConfig, err := c.TLSConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	host := c.Endpoint()
+	perRPC, source := c.perRPCCredentials()
+
+	authority := formatAuthority(host)
+	if c.TLSServerName != "" {
+		authority = c.TLSServerName
+	}
+
+	dialOpts := []grpc.DialOption{
+		grpc.WithTransportCredentials(
+			credentials.NewTLS(tlsConfig),
+		),
+		grpc.WithAuthority(authority),
+		grpc.WithDefaultCallOptions(
+			grpc.PerRPCCredentials(perRPC),
+		),
+		grpc.WithStatsHandler(otelgrpc.NewClientHandler()),
+	}
+
+	if c.UserAgent != "" {
+		dialOpts = append(dialOpts, grpc.WithUserAgent(c.UserAgent))
+	}
+
+	if source != nil {
+		dialOpts = append(dialOpts, grpc.WithChainUnaryInterceptor(reauthenticate(source)))
+	}
+
+	// the proxy is chosen here rather than left to gRPC, so that the
+	// settings of the configuration apply and errors mention the proxy
+	target := host
+	dialOpts = append(dialOpts, grpc.WithNoProxy())
+
+	if err := c.validateProxy(); err != nil {
+		return nil, err
+	}
+
+	proxy, err := c.proxyFor(withPort(host))
+	if err != nil {
+		return nil, fmt.Errorf("failed to select proxy: %w", err)
+	}
+
+	if proxy != nil {
+		// the proxy resolves the host, it must not be resolved locally
+		target = "passthrough:///" + withPort(host)
+		dialOpts = append(dialOpts, grpc.WithContextDialer(func(ctx context.Context, addr string) (net.Conn, error) {
+			return dialProxy(ctx, proxy, addr)
+		}))
+	}
+
+	return grpc.NewClient(target, append(dialOpts, opts...)...)
+}
+
+// perRPCCredentials returns the credentials attached to every call, and the
+// source to invalidate when the server rejects them, nil for a static token.
+func (c Config) perRPCCredentials() (credentials.PerRPCCredentials, invalidator) {
+	switch {
+	case c.Token != "":
+		return tokenAuth{source: staticToken(c.Token)}, nil
+	case c.TokenFile != "":
+		source := &fileToken{name: c.TokenFile}
+		return tokenAuth{source: source}, source
+	case len(c.TokenCommand) > 0:
+		source := &commandToken{args: c.TokenCommand, now: time.Now}
+		return tokenAuth{source: source}, source
+	default:
+		source := keystone.NewTokenSource(c.Keystone, c.httpClient())
+		return keystone.PerRPCCredentials{Source: source}, source
+	}
+}
+
+func formatAuthority(host string) string {
+	parts := strings.Split(host, ":")
+	if len(parts) == 1 {
+		return host
+	}
+
+	if parts[1] == "443" || parts[1] == "80" {
+		return parts[0]
+	}
+
+	return host
+}
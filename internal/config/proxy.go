@@ -0,0 +1,188 @@
+package config
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"golang.org/x/net/http/httpproxy"
+)
+
+var ErrInvalidProxy = errors.New("invalid proxy URL")
+
+// proxyFromEnv returns the first of the variables that is set.
+func proxyFromEnv(names ...string) string {
+	for _, el := range names {
+		if v := os.Getenv(el); v != "" {
+			return v
+		}
+	}
+
+	return ""
+}
+
+func (c Config) validateProxy() error {
+	if c.ProxyURL == "" {
+		return nil
+	}
+
+	u, err := url.Parse(c.ProxyURL)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidProxy, err)
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("%w: scheme must be http or https, got %q", ErrInvalidProxy, u.Scheme)
+	}
+
+	if u.Host == "" {
+		return fmt.Errorf("%w: missing host", ErrInvalidProxy)
+	}
+
+	return nil
+}
+
+// proxyFunc returns the proxy to use for an URL, nil for a direct connection.
+func (c Config) proxyFunc() func(*url.URL) (*url.URL, error) {
+	return (&httpproxy.Config{
+		HTTPProxy:  c.ProxyURL,
+		HTTPSProxy: c.ProxyURL,
+		NoProxy:    c.NoProxy,
+	}).ProxyFunc()
+}
+
+// proxyFor returns the proxy to connect to addr through, nil for a direct
+// connection.
+func (c Config) proxyFor(addr string) (*url.URL, error) {
+	if c.ProxyURL == "" {
+		return nil, nil
+	}
+
+	return c.proxyFunc()(&url.URL{Scheme: "https", Host: addr})
+}
+
+// httpClient returns the client used for the HTTP requests made on behalf of
+// the connection, such as issuing Keystone tokens.
+func (c Config) httpClient() *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	// the proxy variables were read by FromEnv already, the settings may
+	// have been overridden since
+	transport.Proxy = nil
+	if c.ProxyURL != "" {
+		proxy := c.proxyFunc()
+		transport.Proxy = func(req *http.Request) (*url.URL, error) {
+			return proxy(req.URL)
+		}
+	}
+
+	return &http.Client{Transport: transport}
+}
+
+// withPort adds the default port of the service to host if it has none.
+func withPort(host string) string {
+	if _, _, err := net.SplitHostPort(host); err == nil {
+		return host
+	}
+
+	return net.JoinHostPort(host, "443")
+}
+
+// dialProxy opens a tunnel to addr through an HTTP CONNECT proxy.
+func dialProxy(ctx context.Context, proxy *url.URL, addr string) (net.Conn, error) {
+	conn, err := dialProxyConn(ctx, proxy, addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s through proxy %s: %w", addr, proxy.Redacted(), err)
+	}
+
+	return conn, nil
+}
+
+func dialProxyConn(ctx context.Context, proxy *url.URL, addr string) (net.Conn, error) {
+	proxyAddr := proxy.Host
+	if proxy.Port() == "" {
+		port := "80"
+		if proxy.Scheme == "https" {
+			port = "443"
+		}
+		proxyAddr = net.JoinHostPort(proxy.Hostname(), port)
+	}
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", proxyAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	if proxy.Scheme == "https" {
+		tlsConn := tls.Client(conn, &tls.Config{
+			MinVersion: tls.VersionTLS12,
+			ServerName: proxy.Hostname(),
+		})
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		conn = tlsConn
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+		defer conn.SetDeadline(time.Time{}) //nolint:errcheck // best effort
+	}
+
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: addr},
+		Host:   addr,
+		Header: http.Header{},
+	}
+
+	if proxy.User != nil {
+		password, _ := proxy.User.Password()
+		credentials := base64.StdEncoding.EncodeToString([]byte(proxy.User.Username() + ":" + password))
+		req.Header.Set("Proxy-Authorization", "Basic "+credentials)
+	}
+
+	if err := req.Write(conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	r := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(r, req)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		conn.Close()
+		return nil, fmt.Errorf("proxy responded %s", resp.Status)
+	}
+
+	if r.Buffered() > 0 {
+		return &bufferedConn{Conn: conn, r: r}, nil
+	}
+
+	return conn, nil
+}
+
+// bufferedConn reads the data the proxy sent past its response first.
+type bufferedConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (c *bufferedConn) Read(b []byte) (int, error) {
+	return c.r.Read(b)
+}
@@ -0,0 +1,146 @@
+package config
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// startProxy starts a CONNECT proxy requiring the credentials user:secret,
+// which tunnels every request to target.
+func startProxy(t *testing.T, target string) string {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { lis.Close() })
+
+	go func() {
+		for {
+			conn, err := lis.Accept()
+			if err != nil {
+				return
+			}
+
+			go func() {
+				defer conn.Close()
+
+				req, err := http.ReadRequest(bufio.NewReader(conn))
+				if err != nil {
+					return
+				}
+
+				if user, password, ok := (&http.Request{Header: http.Header{
+					"Authorization": req.Header.Values("Proxy-Authorization"),
+				}}).BasicAuth(); !ok || user != "user" || password != "secret" {
+					_, _ = io.WriteString(conn, "HTTP/1.1 407 Proxy Authentication Required\r\n\r\n")
+					return
+				}
+
+				upstream, err := net.Dial("tcp", target)
+				if err != nil {
+					_, _ = io.WriteString(conn, "HTTP/1.1 502 Bad Gateway\r\n\r\n")
+					return
+				}
+				defer upstream.Close()
+
+				_, _ = io.WriteString(conn, "HTTP/1.1 200 Connection established\r\n\r\n")
+				go func() { _, _ = io.Copy(upstream, conn) }()
+				_, _ = io.Copy(conn, upstream)
+			}()
+		}
+	}()
+
+	return lis.Addr().String()
+}
+
+// startEcho starts a server answering "hello" to every connection.
+func startEcho(t *testing.T) string {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { lis.Close() })
+
+	go func() {
+		for {
+			conn, err := lis.Accept()
+			if err != nil {
+				return
+			}
+			_, _ = io.WriteString(conn, "hello")
+			conn.Close()
+		}
+	}()
+
+	return lis.Addr().String()
+}
+
+func TestDialProxy(t *testing.T) {
+	proxyAddr := startProxy(t, startEcho(t))
+
+	proxy, _ := url.Parse("http://user:secret@" + proxyAddr)
+	conn, err := dialProxy(context.Background(), proxy, "api.example.test:443")
+	if err != nil {
+		t.Fatalf("dialProxy() error = %v", err)
+	}
+	defer conn.Close()
+
+	got, _ := io.ReadAll(conn)
+	if string(got) != "hello" {
+		t.Errorf("read %q through the tunnel, want %q", got, "hello")
+	}
+}
+
+func TestDialProxy_unauthorized(t *testing.T) {
+	proxyAddr := startProxy(t, startEcho(t))
+
+	proxy, _ := url.Parse("http://user:wrong@" + proxyAddr)
+	_, err := dialProxy(context.Background(), proxy, "api.example.test:443")
+	if err == nil {
+		t.Fatal("dialProxy() succeeded with wrong credentials")
+	}
+
+	for _, want := range []string{"through proxy http://user:xxxxx@" + proxyAddr, "407"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("dialProxy() error = %v, want it to contain %q", err, want)
+		}
+	}
+
+	if strings.Contains(err.Error(), "wrong") {
+		t.Errorf("dialProxy() error = %v, leaks the password", err)
+	}
+}
+
+func TestProxyFor(t *testing.T) {
+	cfg := Config{
+		ProxyURL: "http://proxy.example.test:3128",
+		NoProxy:  "internal.example.test,10.0.0.0/8",
+	}
+
+	tests := map[string]bool{
+		"managed-kubernetes.waw4-1.cloudferro.com:443": true,
+		"api.internal.example.test:443":                false,
+		"10.1.2.3:8443":                                false,
+	}
+
+	for addr, proxied := range tests {
+		proxy, err := cfg.proxyFor(addr)
+		if err != nil {
+			t.Fatalf("proxyFor(%q) error = %v", addr, err)
+		}
+
+		if (proxy != nil) != proxied {
+			t.Errorf("proxyFor(%q) = %v, want proxied %v", addr, proxy, proxied)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	clusterservice "gitlab.cloudferro.com/k8s/api/clusterservice/v1"
+	nodepoolservice "gitlab.cloudferro.com/k8s/api/nodepoolservice/v1"
+	"google.golang.org/grpc"
+)
+
+// ErrReadOnly is returned by the calls blocked in read-only mode.
+var ErrReadOnly = errors.New("read-only mode")
+
+// mutatingMethods are the full methods, without the leading slash, which
+// change objects of the service.
+var mutatingMethods = map[string]bool{}
+
+func init() {
+	for _, el := range []struct {
+		service string
+		methods []string
+	}{
+		{clusterservice.Cluster_ServiceDesc.ServiceName, []string{"CreateCluster", "UpdateCluster", "DeleteCluster"}},
+		{nodepoolservice.NodePool_ServiceDesc.ServiceName, []string{"CreateNodePool", "UpdateNodePool", "DeleteNodePool"}},
+	} {
+		for _, method := range el.methods {
+			mutatingMethods[el.service+"/"+method] = true
+		}
+	}
+}
+
+// readOnly is a unary interceptor failing the calls which change objects
+// with ErrReadOnly, before they are sent.
+func readOnly(
+	ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker,
+	opts ...grpc.CallOption,
+) error {
+	name := strings.TrimPrefix(method, "/")
+	if mutatingMethods[name] {
+		return fmt.Errorf("%w: %s is not allowed", ErrReadOnly, name[strings.LastIndex(name, "/")+1:])
+	}
+
+	return invoker(ctx, method, req, reply, cc, opts...)
+}
@@ -0,0 +1,34 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	clusterservice "gitlab.cloudferro.com/k8s/api/clusterservice/v1"
+	nodepoolservice "gitlab.cloudferro.com/k8s/api/nodepoolservice/v1"
+	"google.golang.org/grpc"
+)
+
+func TestReadOnly(t *testing.T) {
+	clusters := "/" + clusterservice.Cluster_ServiceDesc.ServiceName
+	nodePools := "/" + nodepoolservice.NodePool_ServiceDesc.ServiceName
+
+	tests := map[string]bool{
+		clusters + "/DeleteCluster":   true,
+		nodePools + "/UpdateNodePool": true,
+		clusters + "/GetCluster":      false,
+		nodePools + "/ListNodePools":  false,
+	}
+
+	invoker := func(context.Context, string, any, any, *grpc.ClientConn, ...grpc.CallOption) error {
+		return nil
+	}
+
+	for method, blocked := range tests {
+		err := readOnly(context.Background(), method, nil, nil, nil, invoker)
+		if errors.Is(err, ErrReadOnly) != blocked {
+			t.Errorf("readOnly(%s) = %v, want blocked %v", method, err, blocked)
+		}
+	}
+}
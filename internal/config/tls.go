@@ -0,0 +1,107 @@
+package config
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+var (
+	ErrServerCertSources = errors.New("server certificate path and PEM content are mutually exclusive")
+	ErrClientCertKey     = errors.New("client certificate and key must be set together")
+	ErrInsecureCert      = errors.New("insecure and a server certificate are mutually exclusive")
+)
+
+// validateTLS reports conflicting TLS settings, without reading any file.
+func (c Config) validateTLS() error {
+	var errs []error
+
+	if c.ServerCert != "" && c.ServerCertPEM != "" {
+		errs = append(errs, fmt.Errorf("%w: set only one of %s and %s", ErrServerCertSources, EnvCert, EnvCertPEM))
+	}
+
+	if (c.ClientCert == "") != (c.ClientKey == "") {
+		errs = append(errs, fmt.Errorf("%w: set both %s and %s", ErrClientCertKey, EnvClientCert, EnvClientKey))
+	}
+
+	if c.Insecure && (c.ServerCert != "" || c.ServerCertPEM != "") {
+		errs = append(errs, fmt.Errorf("%w: unset %s or the server certificate", ErrInsecureCert, EnvInsecure))
+	}
+
+	return errors.Join(errs...)
+}
+
+// TLSConfig returns the TLS configuration of the connection, loading the
+// certificates and key.
+func (c Config) TLSConfig() (*tls.Config, error) {
+	if err := c.validateTLS(); err != nil {
+		return nil, err
+	}
+
+	cfg := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         c.TLSServerName,
+		InsecureSkipVerify: c.Insecure, //nolint:gosec // explicitly requested for lab setups
+	}
+
+	serverCert := []byte(c.ServerCertPEM)
+	if c.ServerCert != "" {
+		var err error
+		serverCert, err = os.ReadFile(c.ServerCert)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load server certificate: %w", err)
+		}
+	}
+
+	// the server certificate may be the only one needed, callers warn about
+	// the system ones with SystemCertsError
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+
+	if len(serverCert) > 0 && !pool.AppendCertsFromPEM(serverCert) {
+		return nil, errors.New("failed to load server certificate: no PEM-encoded certificate found")
+	}
+	cfg.RootCAs = pool
+
+	if c.ClientCert != "" {
+		certPEM, err := pemOrFile(c.ClientCert)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+
+		keyPEM, err := pemOrFile(c.ClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client key: %w", err)
+		}
+
+		pair, err := tls.X509KeyPair(certPEM, keyPEM)
+		if err != nil {
+			return nil, fmt.Errorf("invalid client certificate or key: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{pair}
+	}
+
+	return cfg, nil
+}
+
+// SystemCertsError returns the error loading the system certificates, which
+// TLSConfig leaves out of the trusted ones.
+func SystemCertsError() error {
+	_, err := x509.SystemCertPool()
+	return err
+}
+
+// pemOrFile returns value when it is PEM-encoded content, and otherwise reads
+// the file it points to.
+func pemOrFile(value string) ([]byte, error) {
+	if strings.Contains(value, "-----BEGIN ") {
+		return []byte(value), nil
+	}
+
+	return os.ReadFile(value)
+}
@@ -0,0 +1,81 @@
+package config
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cloudferro/terraform-provider-cloudferro/internal/fakeapi"
+)
+
+func TestTLSConfig_inline(t *testing.T) {
+	cert, certPEM, err := fakeapi.SelfSignedCertificate("private.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyDER, err := x509.MarshalPKCS8PrivateKey(cert.PrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})
+
+	// the key is read from a file, the certificates are inline
+	keyFile := filepath.Join(t.TempDir(), "client.key")
+	if err := os.WriteFile(keyFile, keyPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Config{
+		ServerCertPEM: string(certPEM),
+		ClientCert:    string(certPEM),
+		ClientKey:     keyFile,
+		TLSServerName: "private.example.com",
+	}.TLSConfig()
+	if err != nil {
+		t.Fatalf("TLSConfig() error = %v", err)
+	}
+
+	if len(cfg.Certificates) != 1 {
+		t.Errorf("TLSConfig() has %d client certificates, want 1", len(cfg.Certificates))
+	}
+
+	if cfg.ServerName != "private.example.com" {
+		t.Errorf("ServerName = %q, want %q", cfg.ServerName, "private.example.com")
+	}
+}
+
+func TestTLSConfig_invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+		want error
+	}{
+		{
+			name: "server cert path and content",
+			cfg:  Config{ServerCert: "ca.pem", ServerCertPEM: "-----BEGIN CERTIFICATE-----"},
+			want: ErrServerCertSources,
+		},
+		{
+			name: "client cert without key",
+			cfg:  Config{ClientCert: "client.pem"},
+			want: ErrClientCertKey,
+		},
+		{
+			name: "insecure with server cert",
+			cfg:  Config{Insecure: true, ServerCert: "ca.pem"},
+			want: ErrInsecureCert,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.cfg.TLSConfig(); !errors.Is(err, tt.want) {
+				t.Errorf("TLSConfig() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
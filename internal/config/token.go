@@ -0,0 +1,264 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+)
+
+// tokenCommandTimeout bounds a run of the token command.
+const tokenCommandTimeout = 30 * time.Second
+
+// tokenSource provides the API token sent with every call.
+type tokenSource interface {
+	Token(ctx context.Context) (string, error)
+}
+
+// invalidator is implemented by token sources that can obtain a new token
+// after the server rejected the current one.
+type invalidator interface {
+	Invalidate()
+}
+
+type staticToken string
+
+func (t staticToken) Token(context.Context) (string, error) {
+	return string(t), nil
+}
+
+// fileToken reads the token from a file, again whenever the file changes.
+type fileToken struct {
+	name string
+
+	mu      sync.Mutex
+	token   string
+	modTime time.Time
+	size    int64
+}
+
+func (f *fileToken) Token(context.Context) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	info, err := os.Stat(f.name)
+	if err != nil {
+		return "", fmt.Errorf("failed to read token file: %w", err)
+	}
+
+	if f.token != "" && info.ModTime().Equal(f.modTime) && info.Size() == f.size {
+		return f.token, nil
+	}
+
+	data, err := os.ReadFile(f.name)
+	if err != nil {
+		return "", fmt.Errorf("failed to read token file: %w", err)
+	}
+
+	token := strings.TrimSpace(string(data))
+	if token == "" {
+		return "", fmt.Errorf("token file %s is empty", f.name)
+	}
+
+	f.token, f.modTime, f.size = token, info.ModTime(), info.Size()
+	return token, nil
+}
+
+func (f *fileToken) Invalidate() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.token = ""
+}
+
+// commandToken runs a credential helper and caches the token it prints until
+// it expires or the server rejects it. The helper prints either the bare
+// token or an ExecCredential object like the kubectl exec plugins do.
+type commandToken struct {
+	args []string
+	now  func() time.Time
+
+	mu        sync.Mutex
+	token     string
+	expiresAt time.Time
+}
+
+type execCredential struct {
+	Status struct {
+		Token               string    `json:"token"`
+		ExpirationTimestamp time.Time `json:"expirationTimestamp"`
+	} `json:"status"`
+}
+
+func (c *commandToken) Token(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.token != "" && (c.expiresAt.IsZero() || c.now().Before(c.expiresAt)) {
+		return c.token, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, tokenCommandTimeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, c.args[0], c.args[1:]...) //nolint:gosec // configured by the user
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("token command %q failed: %w: %s", c.args[0], err, msg)
+		}
+		return "", fmt.Errorf("token command %q failed: %w", c.args[0], err)
+	}
+
+	out := bytes.TrimSpace(stdout.Bytes())
+
+	var token string
+	var expiresAt time.Time
+	if bytes.HasPrefix(out, []byte("{")) {
+		var cred execCredential
+		if err := json.Unmarshal(out, &cred); err != nil {
+			return "", fmt.Errorf("token command %q printed an invalid credential: %w", c.args[0], err)
+		}
+		token, expiresAt = cred.Status.Token, cred.Status.ExpirationTimestamp
+	} else {
+		token = string(out)
+	}
+
+	if token == "" {
+		return "", fmt.Errorf("token command %q printed no token", c.args[0])
+	}
+
+	c.token, c.expiresAt = token, expiresAt
+	return token, nil
+}
+
+func (c *commandToken) Invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.token = ""
+}
+
+// splitCommand splits a command line into its arguments like a POSIX shell
+// does, without expanding anything: single quotes keep their content as is,
+// double quotes and backslashes escape spaces and quotes.
+func splitCommand(s string) ([]string, error) {
+	var (
+		args  []string
+		arg   strings.Builder
+		inArg bool
+		quote rune
+	)
+
+	runes := []rune(s)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+
+		switch {
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				arg.WriteRune(r)
+			}
+		case r == '\\':
+			if i+1 == len(runes) {
+				return nil, fmt.Errorf("%w: trailing backslash", ErrInvalidTokenCommand)
+			}
+			// in double quotes, the backslash only escapes itself and the
+			// double quote
+			if next := runes[i+1]; quote == 0 || next == '"' || next == '\\' {
+				r = next
+				i++
+			}
+			arg.WriteRune(r)
+			inArg = true
+		case quote == '"':
+			if r == '"' {
+				quote = 0
+			} else {
+				arg.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				inArg = false
+			}
+		default:
+			arg.WriteRune(r)
+			inArg = true
+		}
+	}
+
+	if quote != 0 {
+		return nil, fmt.Errorf("%w: unterminated %c quote", ErrInvalidTokenCommand, quote)
+	}
+
+	if inArg {
+		args = append(args, arg.String())
+	}
+
+	return args, nil
+}
+
+var _ credentials.PerRPCCredentials = (*tokenAuth)(nil)
+
+type tokenAuth struct {
+	source tokenSource
+}
+
+// GetRequestMetadata implements [credentials.PerRPCCredentials].
+func (t tokenAuth) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	token, err := t.source.Token(ctx)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+
+	return map[string]string{
+		"Authorization": "Token " + token,
+	}, nil
+}
+
+// RequireTransportSecurity implements [credentials.PerRPCCredentials].
+func (t tokenAuth) RequireTransportSecurity() bool {
+	return true
+}
+
+// reauthenticate retries a call once with a new token when the server
+// rejects the current one. Rejected calls are not processed, so retrying
+// mutating ones is safe.
+func reauthenticate(source invalidator) grpc.UnaryClientInterceptor {
+	return func(
+		ctx context.Context,
+		method string,
+		req, reply any,
+		cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker,
+		opts ...grpc.CallOption,
+	) error {
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		if status.Code(err) != codes.Unauthenticated {
+			return err
+		}
+
+		source.Invalidate()
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
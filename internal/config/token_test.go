@@ -0,0 +1,189 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestFileToken(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(name, []byte("first\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	source := &fileToken{name: name}
+
+	if token, err := source.Token(context.Background()); err != nil || token != "first" {
+		t.Fatalf("Token() = %q, %v, want %q", token, err, "first")
+	}
+
+	// rotated by the CI runner
+	if err := os.WriteFile(name, []byte("second-token\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if token, err := source.Token(context.Background()); err != nil || token != "second-token" {
+		t.Errorf("Token() = %q, %v, want %q", token, err, "second-token")
+	}
+}
+
+func TestCommandToken(t *testing.T) {
+	counter := filepath.Join(t.TempDir(), "runs")
+	script := `echo run >> "$1"; echo '{"kind":"ExecCredential","status":{"token":"exec-token","expirationTimestamp":"2030-01-01T00:00:00Z"}}'`
+
+	now := time.Date(2029, 1, 1, 0, 0, 0, 0, time.UTC)
+	source := &commandToken{
+		args: []string{"sh", "-c", script, "sh", counter},
+		now:  func() time.Time { return now },
+	}
+
+	runs := func() int {
+		data, _ := os.ReadFile(counter)
+		return len(data) / len("run\n")
+	}
+
+	for range 2 {
+		if token, err := source.Token(context.Background()); err != nil || token != "exec-token" {
+			t.Fatalf("Token() = %q, %v, want %q", token, err, "exec-token")
+		}
+	}
+
+	if runs() != 1 {
+		t.Errorf("command ran %d times, want the token cached", runs())
+	}
+
+	now = now.AddDate(2, 0, 0)
+	if _, err := source.Token(context.Background()); err != nil {
+		t.Fatalf("Token() error = %v", err)
+	}
+
+	if runs() != 2 {
+		t.Errorf("command ran %d times, want it run again after expiry", runs())
+	}
+}
+
+func TestCommandToken_failure(t *testing.T) {
+	source := &commandToken{
+		args: []string{"sh", "-c", "echo locked >&2; exit 1"},
+		now:  time.Now,
+	}
+
+	_, err := source.Token(context.Background())
+	if err == nil || err.Error() != `token command "sh" failed: exit status 1: locked` {
+		t.Errorf("Token() error = %v", err)
+	}
+}
+
+type countingInvalidator int
+
+func (c *countingInvalidator) Invalidate() { *c++ }
+
+func TestReauthenticate(t *testing.T) {
+	var invalidated countingInvalidator
+	calls := 0
+
+	invoker := func(context.Context, string, any, any, *grpc.ClientConn, ...grpc.CallOption) error {
+		calls++
+		if calls == 1 {
+			return status.Error(codes.Unauthenticated, "token expired")
+		}
+		return nil
+	}
+
+	err := reauthenticate(&invalidated)(context.Background(), "/Test", nil, nil, nil, invoker)
+	if err != nil {
+		t.Errorf("call error = %v, want the retry to succeed", err)
+	}
+
+	if calls != 2 || invalidated != 1 {
+		t.Errorf("calls = %d, invalidated = %d, want one retry with a new token", calls, invalidated)
+	}
+}
+
+func TestSplitCommand(t *testing.T) {
+	tests := []struct {
+		name    string
+		command string
+		want    []string
+		wantErr string
+	}{
+		{name: "empty", command: "  "},
+		{
+			name:    "spaces",
+			command: " pass  show\tcloudferro/token ",
+			want:    []string{"pass", "show", "cloudferro/token"},
+		},
+		{
+			name:    "double quotes",
+			command: `vault read -field=token "secret/my path"`,
+			want:    []string{"vault", "read", "-field=token", "secret/my path"},
+		},
+		{
+			name:    "single quotes",
+			command: `sh -c 'echo "$TOKEN"' ''`,
+			want:    []string{"sh", "-c", `echo "$TOKEN"`, ""},
+		},
+		{
+			name:    "escapes",
+			command: `op read op://vault/my\ item/token "say \"hi\" \n"`,
+			want:    []string{"op", "read", "op://vault/my item/token", `say "hi" \n`},
+		},
+		{
+			name:    "unterminated quote",
+			command: `vault read "secret/my path`,
+			wantErr: `invalid token command: unterminated " quote`,
+		},
+		{
+			name:    "trailing backslash",
+			command: `vault read \`,
+			wantErr: "invalid token command: trailing backslash",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := splitCommand(tt.command)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("splitCommand() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("splitCommand() error = %v", err)
+			}
+
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("splitCommand() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFromEnv_invalidTokenCommand(t *testing.T) {
+	t.Setenv(EnvToken, "")
+	t.Setenv(EnvTokenFile, "")
+	t.Setenv(EnvTokenCommand, `vault read "secret/my path`)
+	t.Setenv(EnvRegion, "waw4-1")
+	t.Setenv(EnvHost, "")
+
+	cfg := FromEnv()
+	if err := cfg.Validate(); !errors.Is(err, ErrInvalidTokenCommand) {
+		t.Errorf("Validate() error = %v, want %v", err, ErrInvalidTokenCommand)
+	}
+
+	// a token set in the provider configuration replaces the command
+	cfg.SetTokenSources("token", "", nil)
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+}
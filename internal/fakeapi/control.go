@@ -0,0 +1,106 @@
+package fakeapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// injectErrorRequest is the body of POST /errors. Code is the gRPC status
+// code, either its name ("PERMISSION_DENIED") or its number.
+type injectErrorRequest struct {
+	Method  string     `json:"method"`
+	Code    codes.Code `json:"code"`
+	Message string     `json:"message"`
+	// Count is how many consecutive calls fail, 1 when omitted.
+	Count int `json:"count"`
+}
+
+// injectErrorStateRequest is the body of POST /error-states.
+type injectErrorStateRequest struct {
+	Method  string `json:"method"`
+	Message string `json:"message"`
+}
+
+// ControlHandler returns an HTTP handler which controls the service:
+//
+//	GET  /healthz       liveness check
+//	GET  /state         the current state, as written by Save
+//	POST /reset         remove all clusters and pending injected errors
+//	POST /errors        {"method": "CreateNodePool", "code": "UNAVAILABLE", "message": "..."}
+//	                    fail the next call of the method, see InjectError
+//	POST /error-states  {"method": "CreateCluster", "message": "..."}
+//	                    end the next operation in the Error state, see InjectErrorState
+func (s *Server) ControlHandler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	mux.HandleFunc("GET /state", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if err := s.Save(w); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+
+	mux.HandleFunc("POST /reset", func(w http.ResponseWriter, _ *http.Request) {
+		s.Reset()
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	mux.HandleFunc("POST /errors", func(w http.ResponseWriter, r *http.Request) {
+		var req injectErrorRequest
+		if err := decodeControlRequest(r, &req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if req.Method == "" {
+			http.Error(w, "method must not be empty", http.StatusBadRequest)
+			return
+		}
+
+		if req.Code == codes.OK {
+			http.Error(w, "code must not be OK", http.StatusBadRequest)
+			return
+		}
+
+		for range max(req.Count, 1) {
+			s.InjectError(req.Method, status.Error(req.Code, req.Message))
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	mux.HandleFunc("POST /error-states", func(w http.ResponseWriter, r *http.Request) {
+		var req injectErrorStateRequest
+		if err := decodeControlRequest(r, &req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if req.Method == "" || req.Message == "" {
+			http.Error(w, "method and message must not be empty", http.StatusBadRequest)
+			return
+		}
+
+		s.InjectErrorState(req.Method, req.Message)
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	return mux
+}
+
+func decodeControlRequest(r *http.Request, v any) error {
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("invalid request body: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,109 @@
+package fakeapi_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cloudferro/terraform-provider-cloudferro/client"
+	"github.com/cloudferro/terraform-provider-cloudferro/internal/fakeapi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func postControl(t *testing.T, url, body string) int {
+	t.Helper()
+
+	resp, err := http.Post(url, "application/json", strings.NewReader(body)) //nolint:gosec // test server
+	if err != nil {
+		t.Fatalf("POST %s error = %v", url, err)
+	}
+	resp.Body.Close()
+
+	return resp.StatusCode
+}
+
+func TestControlHandler_errors(t *testing.T) {
+	ctx := testContext(t)
+	srv := fakeapi.New(fakeapi.Options{})
+	cli := newTestClient(srv)
+
+	control := httptest.NewServer(srv.ControlHandler())
+	t.Cleanup(control.Close)
+
+	tests := []struct {
+		name string
+		body string
+		want int
+	}{
+		{
+			name: "code name",
+			body: `{"method": "ListClusters", "code": "UNAVAILABLE", "message": "down", "count": 2}`,
+			want: http.StatusNoContent,
+		},
+		{
+			name: "code number",
+			body: `{"method": "ListClusters", "code": 8, "message": "quota"}`,
+			want: http.StatusNoContent,
+		},
+		{name: "no method", body: `{"code": "UNAVAILABLE"}`, want: http.StatusBadRequest},
+		{name: "OK code", body: `{"method": "ListClusters", "code": "OK"}`, want: http.StatusBadRequest},
+		{name: "unknown field", body: `{"method": "ListClusters", "status": 14}`, want: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := postControl(t, control.URL+"/errors", tt.body); got != tt.want {
+				t.Errorf("POST /errors status = %d, want %d", got, tt.want)
+			}
+		})
+	}
+
+	// the injected errors fail the next calls in order
+	for _, want := range []codes.Code{codes.Unavailable, codes.Unavailable, codes.ResourceExhausted, codes.OK} {
+		if _, err := cli.ListClusters(ctx); status.Code(err) != want {
+			t.Errorf("ListClusters() error = %v, want %s", err, want)
+		}
+	}
+}
+
+func TestControlHandler_errorStates(t *testing.T) {
+	ctx := testContext(t)
+	srv := fakeapi.New(fakeapi.Options{})
+	cli := newTestClient(srv)
+
+	control := httptest.NewServer(srv.ControlHandler())
+	t.Cleanup(control.Close)
+
+	if got := postControl(t, control.URL+"/error-states", `{"method": "CreateCluster"}`); got != http.StatusBadRequest {
+		t.Errorf("POST /error-states without message status = %d, want %d", got, http.StatusBadRequest)
+	}
+
+	body := `{"method": "CreateCluster", "message": "not enough floating ips"}`
+	if got := postControl(t, control.URL+"/error-states", body); got != http.StatusNoContent {
+		t.Fatalf("POST /error-states status = %d, want %d", got, http.StatusNoContent)
+	}
+
+	_, err := cli.CreateCluster(ctx, testClusterSpec, nil)
+	if opErr := (*client.OperationError)(nil); !errors.As(err, &opErr) || opErr.Message != "not enough floating ips" {
+		t.Fatalf("CreateCluster() error = %v, want the injected error state", err)
+	}
+
+	// reset removes the clusters and the injected errors
+	body = `{"method": "CreateCluster", "code": "INTERNAL"}`
+	if got := postControl(t, control.URL+"/errors", body); got != http.StatusNoContent {
+		t.Fatalf("POST /errors status = %d, want %d", got, http.StatusNoContent)
+	}
+	if got := postControl(t, control.URL+"/reset", ""); got != http.StatusNoContent {
+		t.Fatalf("POST /reset status = %d, want %d", got, http.StatusNoContent)
+	}
+
+	if clusters, err := cli.ListClusters(ctx); err != nil || len(clusters) != 0 {
+		t.Errorf("ListClusters() = %d clusters, %v, want none", len(clusters), err)
+	}
+	if _, err := cli.CreateCluster(ctx, testClusterSpec, nil); err != nil {
+		t.Errorf("CreateCluster() error = %v, want the injected errors removed", err)
+	}
+}
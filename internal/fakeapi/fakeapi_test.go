@@ -0,0 +1,35 @@
+package fakeapi_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cloudferro/terraform-provider-cloudferro/client"
+	"github.com/cloudferro/terraform-provider-cloudferro/internal/fakeapi"
+)
+
+// newTestClient returns a client of srv, polling without delay.
+func newTestClient(srv *fakeapi.Server) *client.Client {
+	return client.New(srv.ClientConn(), client.Options{
+		PollInterval: time.Millisecond,
+		MinBackoff:   time.Millisecond,
+		MaxBackoff:   time.Millisecond,
+	})
+}
+
+func testContext(t *testing.T) context.Context {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	t.Cleanup(cancel)
+
+	return ctx
+}
+
+var testClusterSpec = client.ClusterSpec{
+	Name:             "test",
+	Version:          "1.30.10",
+	Flavor:           "eo2a.large",
+	ControlPlaneSize: 1,
+}
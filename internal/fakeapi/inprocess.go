@@ -0,0 +1,80 @@
+package fakeapi
+
+import (
+	"context"
+	"reflect"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// ClientConn returns a connection calling the services in process, without
+// network nor serialization, for the tests of the client. Authentication,
+// latency and injected errors apply as to the calls over the network.
+func (s *Server) ClientConn() grpc.ClientConnInterface {
+	conn := &inProcessConn{s: s, services: map[string]reflect.Value{}}
+	s.Register(conn)
+
+	return conn
+}
+
+type inProcessConn struct {
+	s        *Server
+	services map[string]reflect.Value
+}
+
+// RegisterService implements grpc.ServiceRegistrar.
+func (c *inProcessConn) RegisterService(desc *grpc.ServiceDesc, impl any) {
+	c.services[desc.ServiceName] = reflect.ValueOf(impl)
+}
+
+// Invoke implements grpc.ClientConnInterface.
+func (c *inProcessConn) Invoke(ctx context.Context, method string, args, reply any, _ ...grpc.CallOption) error {
+	var fn reflect.Value
+	if service, name, ok := strings.Cut(strings.TrimPrefix(method, "/"), "/"); ok {
+		if impl, ok := c.services[service]; ok {
+			fn = impl.MethodByName(name)
+		}
+	}
+	if !fn.IsValid() {
+		return status.Errorf(codes.Unimplemented, "unknown method %s", method)
+	}
+
+	if md, ok := metadata.FromOutgoingContext(ctx); ok {
+		ctx = metadata.NewIncomingContext(ctx, md)
+	}
+
+	out, err := c.s.intercept(ctx, args, &grpc.UnaryServerInfo{FullMethod: method},
+		func(ctx context.Context, req any) (any, error) {
+			res := fn.Call([]reflect.Value{reflect.ValueOf(ctx), reflect.ValueOf(req)})
+			err, _ := res[1].Interface().(error)
+			return res[0].Interface(), err
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	dst, ok := reply.(proto.Message)
+	if !ok {
+		return status.Errorf(codes.Internal, "reply of %s is not a message: %T", method, reply)
+	}
+	// merged rather than copied, the messages carry internal state
+	if src, ok := out.(proto.Message); ok && src.ProtoReflect().IsValid() {
+		proto.Merge(dst, src)
+	}
+
+	return nil
+}
+
+// NewStream implements grpc.ClientConnInterface. The services have no
+// streaming methods.
+func (c *inProcessConn) NewStream(
+	context.Context, *grpc.StreamDesc, string, ...grpc.CallOption,
+) (grpc.ClientStream, error) {
+	return nil, status.Error(codes.Unimplemented, "streams are not supported")
+}
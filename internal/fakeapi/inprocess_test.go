@@ -0,0 +1,36 @@
+package fakeapi_test
+
+import (
+	"testing"
+
+	"github.com/cloudferro/terraform-provider-cloudferro/internal/fakeapi"
+	"gitlab.cloudferro.com/k8s/api/cluster/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestServer_ClientConn(t *testing.T) {
+	ctx := testContext(t)
+	srv := fakeapi.New(fakeapi.Options{})
+	cli := newTestClient(srv)
+
+	created, err := cli.CreateCluster(ctx, testClusterSpec, nil)
+	if err != nil {
+		t.Fatalf("CreateCluster() error = %v", err)
+	}
+
+	// replies are copies, changing them leaves the server state alone
+	created.Name = "changed"
+	got, err := cli.GetCluster(ctx, created.GetId())
+	if err != nil {
+		t.Fatalf("GetCluster() error = %v", err)
+	}
+	if got.GetName() != testClusterSpec.Name {
+		t.Errorf("GetCluster() name = %q, want %q", got.GetName(), testClusterSpec.Name)
+	}
+
+	err = srv.ClientConn().Invoke(ctx, "/cluster.v1.Unknown/GetCluster", created, &cluster.Cluster{})
+	if status.Code(err) != codes.Unimplemented {
+		t.Errorf("Invoke() of an unknown service error = %v, want Unimplemented", err)
+	}
+}
@@ -0,0 +1,163 @@
+package fakeapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Keystone is a fake of the Keystone v3 token API. It issues tokens for a
+// single application credential and a single user, and serves them at
+// /v3/auth/tokens.
+type Keystone struct {
+	// ApplicationCredentialID and ApplicationCredentialSecret are accepted
+	// by the application_credential method.
+	ApplicationCredentialID     string
+	ApplicationCredentialSecret string
+	// Username and Password are accepted by the password method, the token
+	// must be scoped to ProjectID or ProjectName.
+	Username    string
+	Password    string
+	ProjectID   string
+	ProjectName string
+	// TTL is the lifetime of the issued tokens. Defaults to one hour.
+	TTL time.Duration
+	// NoExpiry issues tokens which do not expire, without expires_at.
+	NoExpiry bool
+
+	mu     sync.Mutex
+	tokens map[string]time.Time
+	issued int
+}
+
+// Issued returns the number of tokens issued so far.
+func (k *Keystone) Issued() int {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	return k.issued
+}
+
+// Valid reports whether token was issued and has not expired. It can be used
+// as Options.VerifyToken.
+func (k *Keystone) Valid(token string) bool {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	expiresAt, ok := k.tokens[token]
+	return ok && (expiresAt.IsZero() || time.Now().Before(expiresAt))
+}
+
+type keystoneAuthRequest struct {
+	Auth struct {
+		Identity struct {
+			Methods               []string `json:"methods"`
+			ApplicationCredential struct {
+				ID     string `json:"id"`
+				Secret string `json:"secret"`
+			} `json:"application_credential"`
+			Password struct {
+				User struct {
+					Name     string `json:"name"`
+					Password string `json:"password"`
+				} `json:"user"`
+			} `json:"password"`
+		} `json:"identity"`
+		Scope struct {
+			Project struct {
+				ID   string `json:"id"`
+				Name string `json:"name"`
+			} `json:"project"`
+		} `json:"scope"`
+	} `json:"auth"`
+}
+
+// ServeHTTP implements http.Handler.
+func (k *Keystone) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/v3/auth/tokens" || r.Method != http.MethodPost {
+		keystoneError(w, http.StatusNotFound, "Not Found", "the resource could not be found")
+		return
+	}
+
+	var req keystoneAuthRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		keystoneError(w, http.StatusBadRequest, "Bad Request", err.Error())
+		return
+	}
+
+	if !k.authenticate(&req) {
+		keystoneError(w, http.StatusUnauthorized, "Unauthorized", "The request you have made requires authentication.")
+		return
+	}
+
+	ttl := k.TTL
+	if ttl == 0 {
+		ttl = time.Hour
+	}
+
+	now := time.Now()
+	token := uuid.NewString()
+	expiresAt := now.Add(ttl)
+	if k.NoExpiry {
+		expiresAt = time.Time{}
+	}
+
+	k.mu.Lock()
+	if k.tokens == nil {
+		k.tokens = map[string]time.Time{}
+	}
+	k.tokens[token] = expiresAt
+	k.issued++
+	k.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Subject-Token", token)
+	w.WriteHeader(http.StatusCreated)
+
+	body := map[string]any{
+		"methods":   req.Auth.Identity.Methods,
+		"issued_at": now.UTC().Format(time.RFC3339Nano),
+	}
+	if !k.NoExpiry {
+		body["expires_at"] = expiresAt.UTC().Format(time.RFC3339Nano)
+	}
+	_ = json.NewEncoder(w).Encode(map[string]any{"token": body})
+}
+
+func (k *Keystone) authenticate(req *keystoneAuthRequest) bool {
+	identity := req.Auth.Identity
+	if len(identity.Methods) != 1 {
+		return false
+	}
+
+	switch identity.Methods[0] {
+	case "application_credential":
+		return k.ApplicationCredentialID != "" &&
+			identity.ApplicationCredential.ID == k.ApplicationCredentialID &&
+			identity.ApplicationCredential.Secret == k.ApplicationCredentialSecret
+	case "password":
+		project := req.Auth.Scope.Project
+		return k.Username != "" &&
+			identity.Password.User.Name == k.Username &&
+			identity.Password.User.Password == k.Password &&
+			((project.ID != "" && project.ID == k.ProjectID) ||
+				(project.Name != "" && project.Name == k.ProjectName))
+	default:
+		return false
+	}
+}
+
+func keystoneError(w http.ResponseWriter, code int, title, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"error": map[string]any{
+			"code":    code,
+			"title":   title,
+			"message": message,
+		},
+	})
+}
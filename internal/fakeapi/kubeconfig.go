@@ -0,0 +1,31 @@
+package fakeapi
+
+import (
+	"encoding/base64"
+	"fmt"
+)
+
+// kubeconfig renders an admin kubeconfig for the cluster. The cluster
+// endpoint does not exist, the file only has the shape of a real one.
+func kubeconfig(c *clusterEntry) string {
+	ca := base64.StdEncoding.EncodeToString([]byte("fake certificate authority of " + c.ID))
+
+	return fmt.Sprintf(`apiVersion: v1
+kind: Config
+clusters:
+- name: %[1]s
+  cluster:
+    server: https://%[2]s:6443
+    certificate-authority-data: %[3]s
+users:
+- name: %[1]s-admin
+  user:
+    token: %[4]s
+contexts:
+- name: %[1]s
+  context:
+    cluster: %[1]s
+    user: %[1]s-admin
+current-context: %[1]s
+`, c.Name, c.RouterIP, ca, c.KubeconfigAuthToken)
+}
@@ -0,0 +1,112 @@
+package fakeapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// snapshot is the persisted state of the service. Injected errors are not part
+// of it, they only live as long as the process.
+type snapshot struct {
+	Clusters     map[string]*clusterEntry `json:"clusters"`
+	Versions     []*versionEntry          `json:"kubernetes_versions"`
+	MachineSpecs []*machineSpecEntry      `json:"machine_specs"`
+}
+
+// Save writes the state of the service to w as JSON. Operations in progress
+// are saved with the time they finish at, so they resume after Load.
+func (s *Server) Save(w io.Writer) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.advance()
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(snapshot{
+		Clusters:     s.clusters,
+		Versions:     s.versions,
+		MachineSpecs: s.machineSpecs,
+	})
+}
+
+// Load replaces the state of the service with the one read from r.
+func (s *Server) Load(r io.Reader) error {
+	var snap snapshot
+	if err := json.NewDecoder(r).Decode(&snap); err != nil {
+		return fmt.Errorf("fakeapi: failed to decode state: %w", err)
+	}
+
+	if snap.Clusters == nil {
+		snap.Clusters = map[string]*clusterEntry{}
+	}
+
+	for _, el := range snap.Clusters {
+		if el.NodePools == nil {
+			el.NodePools = map[string]*nodePoolEntry{}
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.clusters = snap.Clusters
+	if len(snap.Versions) > 0 {
+		s.versions = snap.Versions
+	}
+	if len(snap.MachineSpecs) > 0 {
+		s.machineSpecs = snap.MachineSpecs
+	}
+
+	return nil
+}
+
+// SaveFile writes the state of the service to the file at name. The file is
+// replaced atomically, so a crash never leaves a truncated state behind.
+func (s *Server) SaveFile(name string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(name), filepath.Base(name)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := s.Save(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), name)
+}
+
+// LoadFile replaces the state of the service with the one saved in the file at
+// name. A missing file leaves the state untouched.
+func (s *Server) LoadFile(name string) error {
+	f, err := os.Open(name)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return s.Load(f)
+}
+
+// Reset removes all clusters and pending injected errors.
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.clusters = map[string]*clusterEntry{}
+	s.errors = map[string][]error{}
+	s.errorStates = map[string][]string{}
+}
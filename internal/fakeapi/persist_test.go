@@ -0,0 +1,74 @@
+package fakeapi_test
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/cloudferro/terraform-provider-cloudferro/client"
+	"github.com/cloudferro/terraform-provider-cloudferro/internal/fakeapi"
+)
+
+func TestServer_SaveFile(t *testing.T) {
+	ctx := testContext(t)
+	name := filepath.Join(t.TempDir(), "state.json")
+
+	saved := fakeapi.New(fakeapi.Options{})
+	cli := newTestClient(saved)
+
+	klaster, err := cli.CreateCluster(ctx, testClusterSpec, nil)
+	if err != nil {
+		t.Fatalf("CreateCluster() error = %v", err)
+	}
+
+	size := int32(2)
+	nodePool, err := cli.CreateNodePool(ctx, klaster.GetId(), client.NodePoolSpec{
+		Name:           "pool",
+		Flavor:         "eo2a.xlarge",
+		Size:           &size,
+		SharedNetworks: []string{"0b6e1f3a-5c2d-4e7f-8a9b-1c2d3e4f5a6b"},
+	}, nil)
+	if err != nil {
+		t.Fatalf("CreateNodePool() error = %v", err)
+	}
+
+	if err := saved.SaveFile(name); err != nil {
+		t.Fatalf("SaveFile() error = %v", err)
+	}
+
+	loaded := fakeapi.New(fakeapi.Options{KubernetesVersions: []string{"1.31.6"}})
+	if err := loaded.LoadFile(name); err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+	cli = newTestClient(loaded)
+
+	gotCluster, err := cli.GetCluster(ctx, klaster.GetId())
+	if err != nil {
+		t.Fatalf("GetCluster() error = %v", err)
+	}
+	if gotCluster.GetName() != "test" || gotCluster.GetStatus() != klaster.GetStatus() {
+		t.Errorf("GetCluster() = %q in the %q state, want %q in the %q state",
+			gotCluster.GetName(), gotCluster.GetStatus(), "test", klaster.GetStatus())
+	}
+
+	gotNodePool, err := cli.GetNodePool(ctx, klaster.GetId(), nodePool.GetId())
+	if err != nil {
+		t.Fatalf("GetNodePool() error = %v", err)
+	}
+	if gotNodePool.GetSize() != 2 || gotNodePool.GetMachineSpec().GetName() != "eo2a.xlarge" ||
+		!slices.Equal(gotNodePool.GetSharedNetworks(), nodePool.GetSharedNetworks()) {
+		t.Errorf("GetNodePool() = %v, want %v", gotNodePool, nodePool)
+	}
+
+	// the saved catalog replaces the one of the options
+	if _, err := cli.ResolveVersion(ctx, "1.30.10"); err != nil {
+		t.Errorf("ResolveVersion() error = %v, want the saved version", err)
+	}
+}
+
+func TestServer_LoadFileMissing(t *testing.T) {
+	srv := fakeapi.New(fakeapi.Options{})
+	if err := srv.LoadFile(filepath.Join(t.TempDir(), "missing.json")); err != nil {
+		t.Errorf("LoadFile() error = %v, want none for a missing file", err)
+	}
+}
@@ -0,0 +1,290 @@
+// Package fakeapi implements an in-memory CloudFerro Managed Kubernetes API.
+//
+// It serves the cluster, node pool, Kubernetes version and machine spec
+// services used by the provider, so the provider can be exercised without
+// access to a real region. Clusters and node pools go through the same
+// asynchronous states as in the real service (Creating, Updating, Deleting),
+// transitions take a configurable amount of time and errors can be injected
+// into any call.
+package fakeapi
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudferro/terraform-provider-cloudferro/client"
+	"gitlab.cloudferro.com/k8s/api/clusterservice/v1"
+	"gitlab.cloudferro.com/k8s/api/kubernetesversionservice/v1"
+	"gitlab.cloudferro.com/k8s/api/machinespecservice/v1"
+	"gitlab.cloudferro.com/k8s/api/nodepoolservice/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Statuses reported for clusters and node pools.
+const (
+	StatusCreating = string(client.StatusCreating)
+	StatusRunning  = string(client.StatusRunning)
+	StatusUpdating = string(client.StatusUpdating)
+	StatusDeleting = string(client.StatusDeleting)
+	StatusError    = string(client.StatusError)
+)
+
+// Options configure the behavior of the fake service.
+type Options struct {
+	// Token expected in the Authorization header. Any token is accepted when
+	// empty.
+	Token string
+	// VerifyToken, when set, accepts the Keystone tokens it returns true for
+	// in the X-Auth-Token header, in addition to Token.
+	VerifyToken func(token string) bool
+	// TransitionDelay is how long clusters and node pools stay in the
+	// Creating, Updating and Deleting states.
+	TransitionDelay time.Duration
+	// Latency is added to every call.
+	Latency time.Duration
+	// KubernetesVersions served by the version service, the first one being
+	// the oldest. Defaults to DefaultKubernetesVersions.
+	KubernetesVersions []string
+	// MachineSpecs served by the machine spec service. Defaults to
+	// DefaultMachineSpecs.
+	MachineSpecs []string
+}
+
+var (
+	DefaultKubernetesVersions = []string{"1.29.14", "1.30.10", "1.31.6"}
+	DefaultMachineSpecs       = []string{"eo2a.large", "eo2a.xlarge", "eo2a.2xlarge"}
+)
+
+// Server is the fake service. The zero value is not usable, use New.
+type Server struct {
+	opts Options
+	now  func() time.Time
+
+	mu           sync.Mutex
+	clusters     map[string]*clusterEntry
+	versions     []*versionEntry
+	machineSpecs []*machineSpecEntry
+	errors       map[string][]error
+	errorStates  map[string][]string
+
+	grpc *grpc.Server
+}
+
+// New returns a fake service with an empty project.
+func New(opts Options) *Server {
+	if len(opts.KubernetesVersions) == 0 {
+		opts.KubernetesVersions = DefaultKubernetesVersions
+	}
+
+	if len(opts.MachineSpecs) == 0 {
+		opts.MachineSpecs = DefaultMachineSpecs
+	}
+
+	s := &Server{
+		opts:        opts,
+		now:         time.Now,
+		clusters:    map[string]*clusterEntry{},
+		errors:      map[string][]error{},
+		errorStates: map[string][]string{},
+	}
+
+	for _, el := range opts.KubernetesVersions {
+		s.versions = append(s.versions, &versionEntry{ID: newID(), Version: el, Active: true})
+	}
+
+	for _, el := range opts.MachineSpecs {
+		s.machineSpecs = append(s.machineSpecs, &machineSpecEntry{ID: newID(), Name: el})
+	}
+
+	return s
+}
+
+// Register registers all services on g. Calls are only authenticated and
+// delayed when g was created with the options returned by ServerOptions.
+func (s *Server) Register(g grpc.ServiceRegistrar) {
+	clusterservice.RegisterClusterServer(g, &clusterServer{s: s})
+	nodepoolservice.RegisterNodePoolServer(g, &nodePoolServer{s: s})
+	kubernetesversionservice.RegisterKubernetesVersionServer(g, &versionServer{s: s})
+	machinespecservice.RegisterMachineSpecServer(g, &machineSpecServer{s: s})
+}
+
+// ServerOptions returns the options which install authentication, latency
+// and error injection.
+func (s *Server) ServerOptions() []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(s.intercept),
+	}
+}
+
+// Start serves the fake service over TLS on addr, in the background. It
+// returns the address the service listens on, usable as the provider host,
+// and the PEM-encoded certificate of the service, usable as the provider
+// server certificate.
+func (s *Server) Start(addr string) (string, []byte, error) {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "", nil, err
+	}
+
+	cert, certPEM, err := SelfSignedCertificate(host, "localhost", "127.0.0.1")
+	if err != nil {
+		return "", nil, err
+	}
+
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return lis.Addr().String(), certPEM, s.Serve(lis, cert)
+}
+
+// Serve serves the fake service over TLS on lis, in the background.
+func (s *Server) Serve(lis net.Listener, cert tls.Certificate) error {
+	opts := append(s.ServerOptions(), grpc.Creds(credentials.NewServerTLSFromCert(&cert)))
+
+	s.mu.Lock()
+	if s.grpc != nil {
+		s.mu.Unlock()
+		return fmt.Errorf("fakeapi: server already started")
+	}
+	s.grpc = grpc.NewServer(opts...)
+	s.Register(s.grpc)
+	g := s.grpc
+	s.mu.Unlock()
+
+	go func() {
+		_ = g.Serve(lis)
+	}()
+
+	return nil
+}
+
+// Stop stops serving and closes all connections.
+func (s *Server) Stop() {
+	s.mu.Lock()
+	g := s.grpc
+	s.grpc = nil
+	s.mu.Unlock()
+
+	if g != nil {
+		g.Stop()
+	}
+}
+
+// InjectError makes the next call of method (for example "CreateNodePool")
+// fail with err. Multiple errors for the same method are returned by
+// consecutive calls.
+func (s *Server) InjectError(method string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.errors[method] = append(s.errors[method], err)
+}
+
+// InjectErrorState makes the operation started by the next successful call of
+// method (one of "CreateCluster", "UpdateCluster", "CreateNodePool" or
+// "UpdateNodePool") end in the Error state with msg, instead of Running.
+func (s *Server) InjectErrorState(method, msg string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.errorStates[method] = append(s.errorStates[method], msg)
+}
+
+// ClusterExists reports whether the cluster exists, in any state.
+func (s *Server) ClusterExists(clusterID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.advance()
+	_, ok := s.clusters[clusterID]
+	return ok
+}
+
+// NodePoolExists reports whether the node pool exists, in any state.
+func (s *Server) NodePoolExists(clusterID, nodePoolID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.advance()
+	c, ok := s.clusters[clusterID]
+	if !ok {
+		return false
+	}
+
+	_, ok = c.NodePools[nodePoolID]
+	return ok
+}
+
+func (s *Server) authenticated(ctx context.Context) bool {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	if auth := md.Get("authorization"); s.opts.Token != "" && len(auth) == 1 && auth[0] == "Token "+s.opts.Token {
+		return true
+	}
+
+	if token := md.Get("x-auth-token"); s.opts.VerifyToken != nil && len(token) == 1 && s.opts.VerifyToken(token[0]) {
+		return true
+	}
+
+	return false
+}
+
+func (s *Server) intercept(
+	ctx context.Context,
+	req any,
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (any, error) {
+	if s.opts.Latency > 0 {
+		select {
+		case <-ctx.Done():
+			return nil, status.FromContextError(ctx.Err()).Err()
+		case <-time.After(s.opts.Latency):
+		}
+	}
+
+	if s.opts.Token != "" || s.opts.VerifyToken != nil {
+		if !s.authenticated(ctx) {
+			return nil, status.Error(codes.Unauthenticated, "invalid token")
+		}
+	}
+
+	method := info.FullMethod[strings.LastIndex(info.FullMethod, "/")+1:]
+
+	s.mu.Lock()
+	var err error
+	if queued := s.errors[method]; len(queued) > 0 {
+		err = queued[0]
+		s.errors[method] = queued[1:]
+	}
+	s.mu.Unlock()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return handler(ctx, req)
+}
+
+// takeErrorState returns the injected error message for the operation
+// started by method, if any. Must be called with s.mu held.
+func (s *Server) takeErrorState(method string) string {
+	queued := s.errorStates[method]
+	if len(queued) == 0 {
+		return ""
+	}
+
+	s.errorStates[method] = queued[1:]
+	return queued[0]
+}
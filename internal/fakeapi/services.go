@@ -0,0 +1,435 @@
+package fakeapi
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+
+	"gitlab.cloudferro.com/k8s/api/cluster/v1"
+	"gitlab.cloudferro.com/k8s/api/clusterservice/v1"
+	"gitlab.cloudferro.com/k8s/api/kubernetesversionservice/v1"
+	"gitlab.cloudferro.com/k8s/api/machinespecservice/v1"
+	"gitlab.cloudferro.com/k8s/api/nodepool/v1"
+	"gitlab.cloudferro.com/k8s/api/nodepoolservice/v1"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+func notFound(kind, id string) error {
+	return status.Errorf(codes.NotFound, "%s %s not found", kind, id)
+}
+
+func busy(kind, id, current string) error {
+	return status.Errorf(codes.FailedPrecondition, "%s %s is in the %s state", kind, id, current)
+}
+
+func invalidField(field, description string) error {
+	st := status.New(codes.InvalidArgument, description)
+	if withDetails, err := st.WithDetails(&errdetails.BadRequest{
+		FieldViolations: []*errdetails.BadRequest_FieldViolation{
+			{Field: field, Description: description},
+		},
+	}); err == nil {
+		st = withDetails
+	}
+
+	return st.Err()
+}
+
+func cloneInt32(v *int32) *int32 {
+	if v == nil {
+		return nil
+	}
+
+	c := *v
+	return &c
+}
+
+type clusterServer struct {
+	clusterservice.UnimplementedClusterServer
+	s *Server
+}
+
+// GetCluster implements clusterservice.ClusterServer.
+func (c *clusterServer) GetCluster(
+	_ context.Context, req *clusterservice.GetClusterRequest,
+) (*cluster.Cluster, error) {
+	c.s.mu.Lock()
+	defer c.s.mu.Unlock()
+	c.s.advance()
+
+	entry, ok := c.s.clusters[req.GetClusterId()]
+	if !ok {
+		return nil, notFound("cluster", req.GetClusterId())
+	}
+
+	return c.s.clusterProto(entry, req.GetExtraFields() == "errors"), nil
+}
+
+// ListClusters implements clusterservice.ClusterServer.
+func (c *clusterServer) ListClusters(
+	context.Context, *clusterservice.ListClustersRequest,
+) (*clusterservice.ListClustersResponse, error) {
+	c.s.mu.Lock()
+	defer c.s.mu.Unlock()
+	c.s.advance()
+
+	resp := &clusterservice.ListClustersResponse{}
+	for _, el := range c.s.clusters {
+		resp.Items = append(resp.Items, c.s.clusterProto(el, false))
+	}
+
+	return resp, nil
+}
+
+// GetClusterFiles implements clusterservice.ClusterServer.
+func (c *clusterServer) GetClusterFiles(
+	_ context.Context, req *clusterservice.GetClusterFilesRequest,
+) (*clusterservice.GetClusterFilesResponse, error) {
+	c.s.mu.Lock()
+	defer c.s.mu.Unlock()
+	c.s.advance()
+
+	entry, ok := c.s.clusters[req.GetClusterId()]
+	if !ok {
+		return nil, notFound("cluster", req.GetClusterId())
+	}
+
+	if entry.Status != StatusRunning {
+		return nil, busy("cluster", entry.ID, entry.Status)
+	}
+
+	return &clusterservice.GetClusterFilesResponse{
+		Kubeconfig: kubeconfig(entry),
+	}, nil
+}
+
+// CreateCluster implements clusterservice.ClusterServer.
+func (c *clusterServer) CreateCluster(
+	_ context.Context, req *clusterservice.CreateClusterRequest,
+) (*cluster.Cluster, error) {
+	c.s.mu.Lock()
+	defer c.s.mu.Unlock()
+	c.s.advance()
+
+	spec := req.GetCluster()
+	if spec.GetName() == "" {
+		return nil, invalidField("cluster.name", "name must not be empty")
+	}
+
+	for _, el := range c.s.clusters {
+		if el.Name == spec.GetName() {
+			return nil, status.Errorf(codes.AlreadyExists, "cluster %q already exists", spec.GetName())
+		}
+	}
+
+	version := c.s.version(spec.GetKubernetesVersion().GetId())
+	if version == nil {
+		return nil, invalidField("cluster.kubernetesVersion.id", "unknown kubernetes version")
+	}
+
+	custom := spec.GetControlPlane().GetCustom()
+	if c.s.machineSpec(custom.GetMachineSpec().GetId()) == nil {
+		return nil, invalidField("cluster.controlPlane.custom.machineSpec.id", "unknown machine spec")
+	}
+
+	entry := &clusterEntry{
+		ID:                  newID(),
+		Name:                spec.GetName(),
+		VersionID:           version.ID,
+		ControlPlaneSize:    custom.GetSize(),
+		ControlPlaneSpecID:  custom.GetMachineSpec().GetId(),
+		RouterIP:            fmt.Sprintf("192.0.2.%d", rand.N(250)+2), //nolint:gosec // not a secret
+		OpenstackProjectID:  newID(),
+		NodePools:           map[string]*nodePoolEntry{},
+		KubeconfigAuthToken: newID(),
+	}
+	entry.Status, entry.Transition = c.s.startTransition("CreateCluster", StatusCreating, StatusRunning)
+	c.s.clusters[entry.ID] = entry
+
+	return c.s.clusterProto(entry, false), nil
+}
+
+// UpdateCluster implements clusterservice.ClusterServer.
+func (c *clusterServer) UpdateCluster(
+	_ context.Context, req *clusterservice.UpdateClusterRequest,
+) (*cluster.Cluster, error) {
+	c.s.mu.Lock()
+	defer c.s.mu.Unlock()
+	c.s.advance()
+
+	entry, ok := c.s.clusters[req.GetClusterId()]
+	if !ok {
+		return nil, notFound("cluster", req.GetClusterId())
+	}
+
+	if entry.Status != StatusRunning && entry.Status != StatusError {
+		return nil, busy("cluster", entry.ID, entry.Status)
+	}
+
+	version := c.s.version(req.GetUpdate().GetVersion().GetId())
+	if version == nil {
+		return nil, invalidField("update.version.id", "unknown kubernetes version")
+	}
+
+	entry.VersionID = version.ID
+	entry.Status, entry.Transition = c.s.startTransition("UpdateCluster", StatusUpdating, StatusRunning)
+
+	return c.s.clusterProto(entry, false), nil
+}
+
+// DeleteCluster implements clusterservice.ClusterServer.
+func (c *clusterServer) DeleteCluster(
+	_ context.Context, req *clusterservice.DeleteClusterRequest,
+) (*emptypb.Empty, error) {
+	c.s.mu.Lock()
+	defer c.s.mu.Unlock()
+	c.s.advance()
+
+	entry, ok := c.s.clusters[req.GetClusterId()]
+	if !ok {
+		return nil, notFound("cluster", req.GetClusterId())
+	}
+
+	if entry.Status != StatusRunning && entry.Status != StatusError {
+		return nil, busy("cluster", entry.ID, entry.Status)
+	}
+
+	entry.Status, entry.Transition = c.s.startTransition("DeleteCluster", StatusDeleting, "")
+
+	return &emptypb.Empty{}, nil
+}
+
+type nodePoolServer struct {
+	nodepoolservice.UnimplementedNodePoolServer
+	s *Server
+}
+
+// runningCluster returns the cluster if it accepts node pool changes. Must be
+// called with s.mu held.
+func (s *Server) runningCluster(clusterID string) (*clusterEntry, error) {
+	entry, ok := s.clusters[clusterID]
+	if !ok {
+		return nil, notFound("cluster", clusterID)
+	}
+
+	if entry.Status != StatusRunning {
+		return nil, busy("cluster", entry.ID, entry.Status)
+	}
+
+	return entry, nil
+}
+
+// GetNodePool implements nodepoolservice.NodePoolServer.
+func (n *nodePoolServer) GetNodePool(
+	_ context.Context, req *nodepoolservice.GetNodePoolRequest,
+) (*nodepool.NodePool, error) {
+	n.s.mu.Lock()
+	defer n.s.mu.Unlock()
+	n.s.advance()
+
+	entry, ok := n.s.clusters[req.GetClusterId()]
+	if !ok {
+		return nil, notFound("cluster", req.GetClusterId())
+	}
+
+	np, ok := entry.NodePools[req.GetNodePoolId()]
+	if !ok {
+		return nil, notFound("node pool", req.GetNodePoolId())
+	}
+
+	return n.s.nodePoolProto(np), nil
+}
+
+// ListNodePools implements nodepoolservice.NodePoolServer.
+func (n *nodePoolServer) ListNodePools(
+	_ context.Context, req *nodepoolservice.ListNodePoolsRequest,
+) (*nodepoolservice.ListNodePoolsResponse, error) {
+	n.s.mu.Lock()
+	defer n.s.mu.Unlock()
+	n.s.advance()
+
+	entry, ok := n.s.clusters[req.GetClusterId()]
+	if !ok {
+		return nil, notFound("cluster", req.GetClusterId())
+	}
+
+	resp := &nodepoolservice.ListNodePoolsResponse{}
+	for _, el := range entry.NodePools {
+		resp.Items = append(resp.Items, n.s.nodePoolProto(el))
+	}
+
+	return resp, nil
+}
+
+// CreateNodePool implements nodepoolservice.NodePoolServer.
+func (n *nodePoolServer) CreateNodePool(
+	_ context.Context, req *nodepoolservice.CreateNodePoolRequest,
+) (*nodepool.NodePool, error) {
+	n.s.mu.Lock()
+	defer n.s.mu.Unlock()
+	n.s.advance()
+
+	entry, err := n.s.runningCluster(req.GetClusterId())
+	if err != nil {
+		return nil, err
+	}
+
+	spec := req.GetNodePool()
+	if spec.GetName() == "" {
+		return nil, invalidField("node_pool.name", "name must not be empty")
+	}
+
+	for _, el := range entry.NodePools {
+		if el.Name == spec.GetName() {
+			return nil, status.Errorf(codes.AlreadyExists, "node pool %q already exists", spec.GetName())
+		}
+	}
+
+	if n.s.machineSpec(spec.GetMachineSpec().GetId()) == nil {
+		return nil, invalidField("node_pool.machineSpec.id", "unknown machine spec")
+	}
+
+	np := &nodePoolEntry{
+		ID:             newID(),
+		Name:           spec.GetName(),
+		MachineSpecID:  spec.GetMachineSpec().GetId(),
+		Autoscale:      spec.GetAutoscale(),
+		Size:           cloneInt32(spec.Size),
+		SizeMin:        cloneInt32(spec.SizeMin),
+		SizeMax:        cloneInt32(spec.SizeMax),
+		SharedNetworks: append([]string(nil), spec.GetSharedNetworks()...),
+	}
+
+	for _, el := range spec.GetLabels() {
+		np.Labels = append(np.Labels, labelEntry{Key: el.GetKey(), Value: el.GetValue()})
+	}
+
+	for _, el := range spec.GetTaints() {
+		np.Taints = append(np.Taints, taintEntry{Key: el.GetKey(), Value: el.GetValue(), Effect: int32(el.GetEffect())})
+	}
+
+	np.Status, np.Transition = n.s.startTransition("CreateNodePool", StatusCreating, StatusRunning)
+	entry.NodePools[np.ID] = np
+
+	return n.s.nodePoolProto(np), nil
+}
+
+// UpdateNodePool implements nodepoolservice.NodePoolServer.
+func (n *nodePoolServer) UpdateNodePool(
+	_ context.Context, req *nodepoolservice.UpdateNodePoolRequest,
+) (*nodepool.NodePool, error) {
+	n.s.mu.Lock()
+	defer n.s.mu.Unlock()
+	n.s.advance()
+
+	entry, err := n.s.runningCluster(req.GetClusterId())
+	if err != nil {
+		return nil, err
+	}
+
+	np, ok := entry.NodePools[req.GetNodePoolId()]
+	if !ok {
+		return nil, notFound("node pool", req.GetNodePoolId())
+	}
+
+	if np.Status != StatusRunning && np.Status != StatusError {
+		return nil, busy("node pool", np.ID, np.Status)
+	}
+
+	update := req.GetNodePool()
+	np.Autoscale = update.GetAutoscale()
+	np.Size = cloneInt32(update.Size)
+	np.SizeMin = cloneInt32(update.SizeMin)
+	np.SizeMax = cloneInt32(update.SizeMax)
+	np.SharedNetworks = append([]string(nil), update.GetSharedNetworks()...)
+	np.Status, np.Transition = n.s.startTransition("UpdateNodePool", StatusUpdating, StatusRunning)
+
+	return n.s.nodePoolProto(np), nil
+}
+
+// DeleteNodePool implements nodepoolservice.NodePoolServer.
+func (n *nodePoolServer) DeleteNodePool(
+	_ context.Context, req *nodepoolservice.DeleteNodePoolRequest,
+) (*emptypb.Empty, error) {
+	n.s.mu.Lock()
+	defer n.s.mu.Unlock()
+	n.s.advance()
+
+	entry, ok := n.s.clusters[req.GetClusterId()]
+	if !ok {
+		return nil, notFound("cluster", req.GetClusterId())
+	}
+
+	// node pools of a broken cluster can still be removed
+	if entry.Status != StatusRunning && entry.Status != StatusError {
+		return nil, busy("cluster", entry.ID, entry.Status)
+	}
+
+	np, ok := entry.NodePools[req.GetNodePoolId()]
+	if !ok {
+		return nil, notFound("node pool", req.GetNodePoolId())
+	}
+
+	if np.Status != StatusRunning && np.Status != StatusError {
+		return nil, busy("node pool", np.ID, np.Status)
+	}
+
+	np.Status, np.Transition = n.s.startTransition("DeleteNodePool", StatusDeleting, "")
+
+	return &emptypb.Empty{}, nil
+}
+
+type versionServer struct {
+	kubernetesversionservice.UnimplementedKubernetesVersionServer
+	s *Server
+}
+
+// List implements kubernetesversionservice.KubernetesVersionServer.
+func (v *versionServer) List(
+	_ context.Context, req *kubernetesversionservice.ListRequest,
+) (*kubernetesversionservice.ListResponse, error) {
+	v.s.mu.Lock()
+	defer v.s.mu.Unlock()
+
+	resp := &kubernetesversionservice.ListResponse{}
+	for _, el := range v.s.versions {
+		if req.Version != nil && *req.Version != el.Version {
+			continue
+		}
+
+		if req.IsActive != nil && *req.IsActive != el.Active {
+			continue
+		}
+
+		resp.Items = append(resp.Items, el.proto())
+	}
+
+	return resp, nil
+}
+
+type machineSpecServer struct {
+	machinespecservice.UnimplementedMachineSpecServer
+	s *Server
+}
+
+// List implements machinespecservice.MachineSpecServer.
+func (m *machineSpecServer) List(
+	_ context.Context, req *machinespecservice.ListRequest,
+) (*machinespecservice.ListResponse, error) {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	resp := &machinespecservice.ListResponse{}
+	for _, el := range m.s.machineSpecs {
+		if req.Name != nil && *req.Name != el.Name {
+			continue
+		}
+
+		resp.Items = append(resp.Items, el.proto())
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,289 @@
+package fakeapi
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gitlab.cloudferro.com/k8s/api/cluster/v1"
+	cferror "gitlab.cloudferro.com/k8s/api/error/v1"
+	"gitlab.cloudferro.com/k8s/api/kubernetesversion/v1"
+	"gitlab.cloudferro.com/k8s/api/machinespec/v1"
+	"gitlab.cloudferro.com/k8s/api/nodepool/v1"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// transition is an asynchronous operation in progress. Once At passes the
+// object moves to Target, or is removed when Target is empty.
+type transition struct {
+	Target string    `json:"target"`
+	At     time.Time `json:"at"`
+	Error  string    `json:"error,omitempty"`
+}
+
+type errorEntry struct {
+	ID        string    `json:"id"`
+	Msg       string    `json:"msg"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type versionEntry struct {
+	ID      string `json:"id"`
+	Version string `json:"version"`
+	Active  bool   `json:"active"`
+}
+
+type machineSpecEntry struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type clusterEntry struct {
+	ID                  string                    `json:"id"`
+	Name                string                    `json:"name"`
+	Status              string                    `json:"status"`
+	VersionID           string                    `json:"version_id"`
+	ControlPlaneSize    int32                     `json:"control_plane_size"`
+	ControlPlaneSpecID  string                    `json:"control_plane_spec_id"`
+	RouterIP            string                    `json:"router_ip"`
+	OpenstackProjectID  string                    `json:"openstack_project_id"`
+	Errors              []errorEntry              `json:"errors,omitempty"`
+	NodePools           map[string]*nodePoolEntry `json:"node_pools"`
+	Transition          *transition               `json:"transition,omitempty"`
+	KubeconfigAuthToken string                    `json:"kubeconfig_auth_token"`
+}
+
+type labelEntry struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+type taintEntry struct {
+	Key    string `json:"key"`
+	Value  string `json:"value"`
+	Effect int32  `json:"effect"`
+}
+
+type nodeEntry struct {
+	Name      string `json:"name"`
+	ServerID  string `json:"server_id"`
+	PrivateIP string `json:"private_ip"`
+}
+
+type nodePoolEntry struct {
+	ID             string       `json:"id"`
+	Name           string       `json:"name"`
+	Status         string       `json:"status"`
+	MachineSpecID  string       `json:"machine_spec_id"`
+	Autoscale      bool         `json:"autoscale"`
+	Size           *int32       `json:"size,omitempty"`
+	SizeMin        *int32       `json:"size_min,omitempty"`
+	SizeMax        *int32       `json:"size_max,omitempty"`
+	SharedNetworks []string     `json:"shared_networks,omitempty"`
+	Labels         []labelEntry `json:"labels,omitempty"`
+	Taints         []taintEntry `json:"taints,omitempty"`
+	Nodes          []nodeEntry  `json:"nodes,omitempty"`
+	Transition     *transition  `json:"transition,omitempty"`
+}
+
+func newID() string {
+	return uuid.NewString()
+}
+
+// startTransition moves an object to status and schedules reaching target.
+// An error state injected for method replaces the target.
+func (s *Server) startTransition(method, status, target string) (string, *transition) {
+	t := &transition{
+		Target: target,
+		At:     s.now().Add(s.opts.TransitionDelay),
+	}
+
+	if msg := s.takeErrorState(method); msg != "" {
+		t.Target = StatusError
+		t.Error = msg
+	}
+
+	return status, t
+}
+
+// advance completes all transitions which are due. Must be called with s.mu
+// held.
+func (s *Server) advance() {
+	now := s.now()
+
+	for id, c := range s.clusters {
+		for npID, np := range c.NodePools {
+			if np.Transition == nil || now.Before(np.Transition.At) {
+				continue
+			}
+
+			t := np.Transition
+			np.Transition = nil
+			if t.Target == "" {
+				delete(c.NodePools, npID)
+				continue
+			}
+
+			np.Status = t.Target
+			if t.Error != "" {
+				c.Errors = append(c.Errors, errorEntry{ID: newID(), Msg: t.Error, CreatedAt: t.At})
+			}
+			np.Nodes = s.nodes(np)
+		}
+
+		if c.Transition == nil || now.Before(c.Transition.At) {
+			continue
+		}
+
+		t := c.Transition
+		c.Transition = nil
+		if t.Target == "" {
+			delete(s.clusters, id)
+			continue
+		}
+
+		c.Status = t.Target
+		if t.Error != "" {
+			c.Errors = append(c.Errors, errorEntry{ID: newID(), Msg: t.Error, CreatedAt: t.At})
+		}
+	}
+}
+
+// nodes returns the nodes of the node pool once it settles.
+func (s *Server) nodes(np *nodePoolEntry) []nodeEntry {
+	if np.Status != StatusRunning {
+		return np.Nodes
+	}
+
+	size := np.Size
+	if np.Autoscale || size == nil {
+		size = np.SizeMin
+	}
+
+	var count int
+	if size != nil {
+		count = int(*size)
+	}
+
+	nodes := np.Nodes
+	for len(nodes) < count {
+		nodes = append(nodes, nodeEntry{
+			Name:      fmt.Sprintf("%s-%s", np.Name, newID()[:8]),
+			ServerID:  newID(),
+			PrivateIP: fmt.Sprintf("10.0.%d.%d", len(nodes)/250, len(nodes)%250+2),
+		})
+	}
+
+	return nodes[:count]
+}
+
+func (s *Server) version(id string) *versionEntry {
+	for _, el := range s.versions {
+		if el.ID == id {
+			return el
+		}
+	}
+
+	return nil
+}
+
+func (s *Server) machineSpec(id string) *machineSpecEntry {
+	for _, el := range s.machineSpecs {
+		if el.ID == id {
+			return el
+		}
+	}
+
+	return nil
+}
+
+func (v *versionEntry) proto() *kubernetesversion.KubernetesVersion {
+	if v == nil {
+		return nil
+	}
+
+	return &kubernetesversion.KubernetesVersion{
+		Id:       v.ID,
+		Version:  v.Version,
+		IsActive: v.Active,
+	}
+}
+
+func (m *machineSpecEntry) proto() *machinespec.MachineSpec {
+	if m == nil {
+		return nil
+	}
+
+	return &machinespec.MachineSpec{
+		Id:   m.ID,
+		Name: m.Name,
+	}
+}
+
+func (s *Server) clusterProto(c *clusterEntry, withErrors bool) *cluster.Cluster {
+	out := &cluster.Cluster{
+		Id:      c.ID,
+		Name:    c.Name,
+		Status:  c.Status,
+		Version: s.version(c.VersionID).proto(),
+		ControlPlane: &cluster.ControlPlane{
+			Custom: &cluster.ControlPlaneCustom{
+				Size:        c.ControlPlaneSize,
+				MachineSpec: s.machineSpec(c.ControlPlaneSpecID).proto(),
+			},
+		},
+		RouterIp: c.RouterIP,
+		Metadata: &cluster.Metadata{
+			OpenstackProjectId: c.OpenstackProjectID,
+		},
+	}
+
+	if withErrors {
+		for _, el := range c.Errors {
+			out.Errors = append(out.Errors, &cferror.Error{
+				Id:        el.ID,
+				Msg:       el.Msg,
+				CreatedAt: timestamppb.New(el.CreatedAt),
+			})
+		}
+	}
+
+	return out
+}
+
+func (s *Server) nodePoolProto(np *nodePoolEntry) *nodepool.NodePool {
+	out := &nodepool.NodePool{
+		Id:             np.ID,
+		Name:           np.Name,
+		Status:         np.Status,
+		MachineSpec:    s.machineSpec(np.MachineSpecID).proto(),
+		Autoscale:      np.Autoscale,
+		Size:           cloneInt32(np.Size),
+		SizeMin:        cloneInt32(np.SizeMin),
+		SizeMax:        cloneInt32(np.SizeMax),
+		SharedNetworks: append([]string(nil), np.SharedNetworks...),
+	}
+
+	for _, el := range np.Labels {
+		out.Labels = append(out.Labels, &nodepool.Label{Key: el.Key, Value: el.Value})
+	}
+
+	for _, el := range np.Taints {
+		out.Taints = append(out.Taints, &nodepool.Taint{
+			Key:    el.Key,
+			Value:  el.Value,
+			Effect: nodepool.Taint_Effect(el.Effect),
+		})
+	}
+
+	for _, el := range np.Nodes {
+		out.Nodes = append(out.Nodes, &nodepool.Node{
+			Name:      el.Name,
+			ServerId:  el.ServerID,
+			PrivateIp: el.PrivateIP,
+			Status:    StatusRunning,
+		})
+	}
+
+	return out
+}
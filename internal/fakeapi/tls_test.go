@@ -0,0 +1,41 @@
+package fakeapi_test
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+
+	"github.com/cloudferro/terraform-provider-cloudferro/internal/fakeapi"
+)
+
+func TestSelfSignedCertificate(t *testing.T) {
+	cert, certPEM, err := fakeapi.SelfSignedCertificate("localhost", "127.0.0.1", "")
+	if err != nil {
+		t.Fatalf("SelfSignedCertificate() error = %v", err)
+	}
+
+	block, _ := pem.Decode(certPEM)
+	if block == nil || block.Type != "CERTIFICATE" {
+		t.Fatalf("SelfSignedCertificate() PEM = %q, want a certificate", certPEM)
+	}
+
+	parsed, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatalf("failed to parse the certificate: %v", err)
+	}
+	if string(cert.Certificate[0]) != string(block.Bytes) {
+		t.Error("SelfSignedCertificate() PEM is not the certificate of the server")
+	}
+
+	roots := x509.NewCertPool()
+	roots.AddCert(parsed)
+
+	for _, host := range []string{"localhost", "127.0.0.1"} {
+		if _, err := parsed.Verify(x509.VerifyOptions{DNSName: host, Roots: roots}); err != nil {
+			t.Errorf("certificate is not valid for %s: %v", host, err)
+		}
+	}
+	if err := parsed.VerifyHostname("example.com"); err == nil {
+		t.Error("certificate is valid for example.com")
+	}
+}
@@ -0,0 +1,59 @@
+// Package k8sversion parses and orders the Kubernetes versions offered by the
+// service, such as "1.31.6".
+package k8sversion
+
+import (
+	"cmp"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Version is a Kubernetes version.
+type Version struct {
+	Major int
+	Minor int
+	Patch int
+}
+
+// Parse parses a version of the form "major.minor.patch", as named by the
+// service.
+func Parse(s string) (Version, error) {
+	parts := strings.Split(s, ".")
+	if len(parts) != 3 {
+		return Version{}, fmt.Errorf("invalid Kubernetes version %q, must be major.minor.patch", s)
+	}
+
+	var nums [3]int
+	for i, el := range parts {
+		n, err := strconv.Atoi(el)
+		if err != nil || n < 0 || el != strconv.Itoa(n) {
+			return Version{}, fmt.Errorf("invalid Kubernetes version %q, must be major.minor.patch", s)
+		}
+		nums[i] = n
+	}
+
+	return Version{Major: nums[0], Minor: nums[1], Patch: nums[2]}, nil
+}
+
+// Compare returns -1, 0 or 1 when v is lower than, equal to or greater than o.
+func (v Version) Compare(o Version) int {
+	if c := cmp.Compare(v.Major, o.Major); c != 0 {
+		return c
+	}
+	if c := cmp.Compare(v.Minor, o.Minor); c != 0 {
+		return c
+	}
+
+	return cmp.Compare(v.Patch, o.Patch)
+}
+
+// NextMinor returns the first version of the next minor release, such as
+// 1.31.0 for 1.30.10. Clusters are upgraded one minor release at a time.
+func (v Version) NextMinor() Version {
+	return Version{Major: v.Major, Minor: v.Minor + 1}
+}
+
+func (v Version) String() string {
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
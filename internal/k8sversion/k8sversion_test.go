@@ -0,0 +1,59 @@
+package k8sversion
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		s       string
+		want    Version
+		wantErr bool
+	}{
+		{s: "1.31.6", want: Version{Major: 1, Minor: 31, Patch: 6}},
+		{s: "v1.30.10", wantErr: true},
+		{s: "1.31", wantErr: true},
+		{s: "1.31.6.1", wantErr: true},
+		{s: "1.31.x", wantErr: true},
+		{s: "1.031.6", wantErr: true},
+		{s: "1.-1.6", wantErr: true},
+		{s: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			got, err := Parse(tt.s)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Parse() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVersion_Compare(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{a: "1.30.10", b: "1.31.6", want: -1},
+		{a: "1.31.6", b: "1.31.6", want: 0},
+		{a: "1.31.10", b: "1.31.9", want: 1},
+		{a: "2.0.0", b: "1.31.6", want: 1},
+	}
+
+	for _, tt := range tests {
+		a, _ := Parse(tt.a)
+		b, _ := Parse(tt.b)
+		if got := a.Compare(b); got != tt.want {
+			t.Errorf("%s.Compare(%s) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestVersion_NextMinor(t *testing.T) {
+	v := Version{Major: 1, Minor: 30, Patch: 10}
+	if got := v.NextMinor().String(); got != "1.31.0" {
+		t.Errorf("NextMinor() = %s, want 1.31.0", got)
+	}
+}
@@ -0,0 +1,316 @@
+// Package keystone obtains OpenStack Keystone tokens for the CloudFerro
+// Managed Kubernetes API, from application credentials or from a user name
+// and password scoped to a project.
+package keystone
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/credentials"
+)
+
+// TokenHeader is the metadata key the token is sent in.
+const TokenHeader = "x-auth-token"
+
+// refreshMargin is how long before expiry a token is replaced. Tokens living
+// shorter than twice the margin are replaced in the second half of their life.
+const refreshMargin = 5 * time.Minute
+
+// Credentials identify a Keystone user. Either the application credential or
+// the user name, password and project are set.
+type Credentials struct {
+	// AuthURL is the Keystone v3 endpoint, such as
+	// "https://keystone.cloudferro.com:5000/v3".
+	AuthURL string
+
+	ApplicationCredentialID     string
+	ApplicationCredentialSecret string
+
+	Username       string
+	Password       string
+	UserDomainName string
+	// ProjectID or ProjectName and ProjectDomainName select the project the
+	// token is scoped to.
+	ProjectID         string
+	ProjectName       string
+	ProjectDomainName string
+}
+
+// IsSet reports whether any credential is set, as opposed to the API token
+// being used.
+func (c Credentials) IsSet() bool {
+	return c.ApplicationCredentialID != "" || c.ApplicationCredentialSecret != "" ||
+		c.Username != "" || c.Password != ""
+}
+
+// Validate reports missing or conflicting settings.
+func (c Credentials) Validate() error {
+	var errs []error
+
+	if c.AuthURL == "" {
+		errs = append(errs, errors.New("auth_url must be set"))
+	}
+
+	appCred := c.ApplicationCredentialID != "" || c.ApplicationCredentialSecret != ""
+	password := c.Username != "" || c.Password != ""
+
+	switch {
+	case appCred && password:
+		errs = append(errs, errors.New("application credentials and username/password are mutually exclusive"))
+	case appCred:
+		if c.ApplicationCredentialID == "" || c.ApplicationCredentialSecret == "" {
+			errs = append(errs, errors.New("both application_credential_id and application_credential_secret must be set"))
+		}
+	case password:
+		if c.Username == "" || c.Password == "" {
+			errs = append(errs, errors.New("both username and password must be set"))
+		}
+		if c.ProjectID == "" && c.ProjectName == "" {
+			errs = append(errs, errors.New("project_id or project_name must be set"))
+		}
+	default:
+		errs = append(errs, errors.New("application credentials or username/password must be set"))
+	}
+
+	return errors.Join(errs...)
+}
+
+// TokenSource issues tokens and caches them until shortly before they expire,
+// or until Invalidate is called for tokens without expiry. It is safe for
+// concurrent use.
+type TokenSource struct {
+	creds Credentials
+	http  *http.Client
+	now   func() time.Time
+
+	mu        sync.Mutex
+	token     string
+	issuedAt  time.Time
+	expiresAt time.Time
+}
+
+// NewTokenSource returns a token source for creds, issuing tokens with
+// httpClient, or http.DefaultClient when nil.
+func NewTokenSource(creds Credentials, httpClient *http.Client) *TokenSource {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	return &TokenSource{
+		creds: creds,
+		http:  httpClient,
+		now:   time.Now,
+	}
+}
+
+// Token returns a valid token, issuing a new one if the cached one expires
+// soon.
+func (s *TokenSource) Token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// a zero expiresAt is a token issued without expiry
+	if s.token != "" && (s.expiresAt.IsZero() || s.now().Before(s.refreshAt())) {
+		return s.token, nil
+	}
+
+	token, expiresAt, err := s.issue(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	s.token = token
+	s.issuedAt = s.now()
+	s.expiresAt = expiresAt
+
+	return token, nil
+}
+
+// Invalidate drops the cached token, the next call to Token issues a new one.
+func (s *TokenSource) Invalidate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.token = ""
+}
+
+func (s *TokenSource) refreshAt() time.Time {
+	margin := min(refreshMargin, s.expiresAt.Sub(s.issuedAt)/2)
+	return s.expiresAt.Add(-margin)
+}
+
+type authRequest struct {
+	Auth struct {
+		Identity identity `json:"identity"`
+		Scope    *scope   `json:"scope,omitempty"`
+	} `json:"auth"`
+}
+
+type identity struct {
+	Methods               []string               `json:"methods"`
+	ApplicationCredential *applicationCredential `json:"application_credential,omitempty"`
+	Password              *password              `json:"password,omitempty"`
+}
+
+type applicationCredential struct {
+	ID     string `json:"id"`
+	Secret string `json:"secret"`
+}
+
+type password struct {
+	User struct {
+		Name     string  `json:"name"`
+		Domain   *domain `json:"domain,omitempty"`
+		Password string  `json:"password"`
+	} `json:"user"`
+}
+
+type domain struct {
+	Name string `json:"name"`
+}
+
+type scope struct {
+	Project struct {
+		ID     string  `json:"id,omitempty"`
+		Name   string  `json:"name,omitempty"`
+		Domain *domain `json:"domain,omitempty"`
+	} `json:"project"`
+}
+
+type authResponse struct {
+	Token struct {
+		ExpiresAt time.Time `json:"expires_at"`
+	} `json:"token"`
+}
+
+type errorResponse struct {
+	Error struct {
+		Code    int    `json:"code"`
+		Title   string `json:"title"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func (s *TokenSource) request() authRequest {
+	var req authRequest
+
+	if s.creds.ApplicationCredentialID != "" {
+		req.Auth.Identity.Methods = []string{"application_credential"}
+		req.Auth.Identity.ApplicationCredential = &applicationCredential{
+			ID:     s.creds.ApplicationCredentialID,
+			Secret: s.creds.ApplicationCredentialSecret,
+		}
+
+		// application credentials are bound to a project, they cannot be
+		// scoped
+		return req
+	}
+
+	pw := &password{}
+	pw.User.Name = s.creds.Username
+	pw.User.Password = s.creds.Password
+	pw.User.Domain = &domain{Name: defaultDomain(s.creds.UserDomainName)}
+
+	req.Auth.Identity.Methods = []string{"password"}
+	req.Auth.Identity.Password = pw
+
+	req.Auth.Scope = &scope{}
+	if s.creds.ProjectID != "" {
+		req.Auth.Scope.Project.ID = s.creds.ProjectID
+	} else {
+		req.Auth.Scope.Project.Name = s.creds.ProjectName
+		req.Auth.Scope.Project.Domain = &domain{Name: defaultDomain(s.creds.ProjectDomainName)}
+	}
+
+	return req
+}
+
+func defaultDomain(name string) string {
+	if name == "" {
+		return "Default"
+	}
+
+	return name
+}
+
+// issue requests a new token from Keystone.
+func (s *TokenSource) issue(ctx context.Context) (string, time.Time, error) {
+	body, err := json.Marshal(s.request())
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	url := strings.TrimSuffix(s.creds.AuthURL, "/") + "/auth/tokens"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("keystone: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.http.Do(req)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("keystone: failed to issue token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("keystone: failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusCreated {
+		var errResp errorResponse
+		if json.Unmarshal(data, &errResp) == nil && errResp.Error.Message != "" {
+			return "", time.Time{}, fmt.Errorf("keystone: failed to issue token: %s: %s",
+				resp.Status, errResp.Error.Message)
+		}
+
+		return "", time.Time{}, fmt.Errorf("keystone: failed to issue token: %s", resp.Status)
+	}
+
+	token := resp.Header.Get("X-Subject-Token")
+	if token == "" {
+		return "", time.Time{}, errors.New("keystone: response has no X-Subject-Token header")
+	}
+
+	var authResp authResponse
+	if err := json.Unmarshal(data, &authResp); err != nil {
+		return "", time.Time{}, fmt.Errorf("keystone: failed to decode response: %w", err)
+	}
+
+	return token, authResp.Token.ExpiresAt, nil
+}
+
+var _ credentials.PerRPCCredentials = (*PerRPCCredentials)(nil)
+
+// PerRPCCredentials attaches a Keystone token to every call, obtaining and
+// refreshing it as needed.
+type PerRPCCredentials struct {
+	Source *TokenSource
+}
+
+// GetRequestMetadata implements [credentials.PerRPCCredentials].
+func (c PerRPCCredentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	token, err := c.Source.Token(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]string{
+		TokenHeader: token,
+	}, nil
+}
+
+// RequireTransportSecurity implements [credentials.PerRPCCredentials].
+func (c PerRPCCredentials) RequireTransportSecurity() bool {
+	return true
+}
@@ -0,0 +1,193 @@
+package keystone
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloudferro/terraform-provider-cloudferro/internal/fakeapi"
+)
+
+func newTestKeystone(t *testing.T, ttl time.Duration) (*fakeapi.Keystone, string) {
+	t.Helper()
+
+	keystone := &fakeapi.Keystone{
+		ApplicationCredentialID:     "app-cred",
+		ApplicationCredentialSecret: "app-secret",
+		Username:                    "user",
+		Password:                    "password",
+		ProjectName:                 "project",
+		TTL:                         ttl,
+	}
+
+	srv := httptest.NewServer(keystone)
+	t.Cleanup(srv.Close)
+
+	return keystone, srv.URL + "/v3"
+}
+
+func TestTokenSource_applicationCredential(t *testing.T) {
+	keystone, authURL := newTestKeystone(t, time.Hour)
+
+	src := NewTokenSource(Credentials{
+		AuthURL:                     authURL,
+		ApplicationCredentialID:     "app-cred",
+		ApplicationCredentialSecret: "app-secret",
+	}, nil)
+
+	md, err := PerRPCCredentials{Source: src}.GetRequestMetadata(context.Background())
+	if err != nil {
+		t.Fatalf("GetRequestMetadata() error = %v", err)
+	}
+
+	if !keystone.Valid(md[TokenHeader]) {
+		t.Errorf("metadata %v does not hold a valid token", md)
+	}
+
+	// the token is cached while it is valid
+	again, err := src.Token(context.Background())
+	if err != nil {
+		t.Fatalf("Token() error = %v", err)
+	}
+
+	if again != md[TokenHeader] || keystone.Issued() != 1 {
+		t.Errorf("Token() issued %d tokens, want the cached one", keystone.Issued())
+	}
+}
+
+func TestTokenSource_refresh(t *testing.T) {
+	keystone, authURL := newTestKeystone(t, time.Hour)
+
+	src := NewTokenSource(Credentials{
+		AuthURL:     authURL,
+		Username:    "user",
+		Password:    "password",
+		ProjectName: "project",
+	}, nil)
+
+	now := time.Now()
+	src.now = func() time.Time { return now }
+
+	first, err := src.Token(context.Background())
+	if err != nil {
+		t.Fatalf("Token() error = %v", err)
+	}
+
+	// still well before expiry
+	now = now.Add(50 * time.Minute)
+	if token, _ := src.Token(context.Background()); token != first {
+		t.Errorf("Token() refreshed the token 10 minutes before its expiry")
+	}
+
+	// within the refresh margin
+	now = now.Add(6 * time.Minute)
+	second, err := src.Token(context.Background())
+	if err != nil {
+		t.Fatalf("Token() error = %v", err)
+	}
+
+	if second == first || keystone.Issued() != 2 {
+		t.Errorf("Token() did not refresh the token close to its expiry")
+	}
+}
+
+func TestTokenSource_noExpiry(t *testing.T) {
+	keystone, authURL := newTestKeystone(t, 0)
+	keystone.NoExpiry = true
+
+	src := NewTokenSource(Credentials{
+		AuthURL:                     authURL,
+		ApplicationCredentialID:     "app-cred",
+		ApplicationCredentialSecret: "app-secret",
+	}, nil)
+
+	now := time.Now()
+	src.now = func() time.Time { return now }
+
+	first, err := src.Token(context.Background())
+	if err != nil {
+		t.Fatalf("Token() error = %v", err)
+	}
+
+	// the token is cached until invalidated
+	now = now.Add(24 * time.Hour)
+	if token, _ := src.Token(context.Background()); token != first || keystone.Issued() != 1 {
+		t.Errorf("Token() issued %d tokens, want the cached one", keystone.Issued())
+	}
+
+	src.Invalidate()
+	second, err := src.Token(context.Background())
+	if err != nil {
+		t.Fatalf("Token() error = %v", err)
+	}
+
+	if second == first || keystone.Issued() != 2 {
+		t.Errorf("Token() did not issue a new token once invalidated")
+	}
+}
+
+func TestTokenSource_invalidCredentials(t *testing.T) {
+	_, authURL := newTestKeystone(t, time.Hour)
+
+	src := NewTokenSource(Credentials{
+		AuthURL:                     authURL,
+		ApplicationCredentialID:     "app-cred",
+		ApplicationCredentialSecret: "wrong",
+	}, nil)
+
+	_, err := src.Token(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "401 Unauthorized") ||
+		!strings.Contains(err.Error(), "requires authentication") {
+		t.Errorf("Token() error = %v, want the Keystone error", err)
+	}
+}
+
+func TestCredentials_Validate(t *testing.T) {
+	tests := []struct {
+		name  string
+		creds Credentials
+		want  string
+	}{
+		{
+			name:  "application credential",
+			creds: Credentials{AuthURL: "u", ApplicationCredentialID: "id", ApplicationCredentialSecret: "s"},
+		},
+		{
+			name:  "password",
+			creds: Credentials{AuthURL: "u", Username: "u", Password: "p", ProjectID: "p"},
+		},
+		{
+			name:  "missing auth url",
+			creds: Credentials{ApplicationCredentialID: "id", ApplicationCredentialSecret: "s"},
+			want:  "auth_url",
+		},
+		{
+			name:  "missing project",
+			creds: Credentials{AuthURL: "u", Username: "u", Password: "p"},
+			want:  "project_id or project_name",
+		},
+		{
+			name:  "both methods",
+			creds: Credentials{AuthURL: "u", ApplicationCredentialID: "id", Username: "u"},
+			want:  "mutually exclusive",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.creds.Validate()
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("Validate() error = %v", err)
+				}
+				return
+			}
+
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Validate() error = %v, want it to mention %q", err, tt.want)
+			}
+		})
+	}
+}
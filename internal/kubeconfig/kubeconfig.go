@@ -0,0 +1,131 @@
+// Package kubeconfig reads the address and credentials from the kubeconfigs
+// of the clusters, for configuring other providers without storing them.
+package kubeconfig
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Credentials are the address and credentials of the current context of a
+// kubeconfig. The certificates and key are PEM-encoded.
+type Credentials struct {
+	Host                 string
+	ClusterCACertificate string
+	ClientCertificate    string
+	ClientKey            string
+	Token                string
+}
+
+// ErrNoContext is returned for kubeconfigs without a context to read.
+var ErrNoContext = errors.New("kubeconfig has no context")
+
+type file struct {
+	CurrentContext string `yaml:"current-context"`
+	Clusters       []struct {
+		Name    string `yaml:"name"`
+		Cluster struct {
+			Server                   string `yaml:"server"`
+			CertificateAuthorityData string `yaml:"certificate-authority-data"`
+		} `yaml:"cluster"`
+	} `yaml:"clusters"`
+	Contexts []struct {
+		Name    string `yaml:"name"`
+		Context struct {
+			Cluster string `yaml:"cluster"`
+			User    string `yaml:"user"`
+		} `yaml:"context"`
+	} `yaml:"contexts"`
+	Users []struct {
+		Name string `yaml:"name"`
+		User struct {
+			ClientCertificateData string `yaml:"client-certificate-data"`
+			ClientKeyData         string `yaml:"client-key-data"`
+			Token                 string `yaml:"token"`
+		} `yaml:"user"`
+	} `yaml:"users"`
+}
+
+// Parse returns the credentials of the current context of the kubeconfig, or
+// of its only context when none is current.
+func Parse(data []byte) (*Credentials, error) {
+	var f file
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("invalid kubeconfig: %w", err)
+	}
+
+	name := f.CurrentContext
+	if name == "" && len(f.Contexts) == 1 {
+		name = f.Contexts[0].Name
+	}
+	if name == "" {
+		return nil, ErrNoContext
+	}
+
+	var creds Credentials
+	var clusterName, userName string
+	found := false
+
+	for _, el := range f.Contexts {
+		if el.Name == name {
+			clusterName, userName = el.Context.Cluster, el.Context.User
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("kubeconfig has no context %q", name)
+	}
+
+	for _, el := range f.Clusters {
+		if el.Name != clusterName {
+			continue
+		}
+
+		ca, err := decode("certificate-authority-data", el.Cluster.CertificateAuthorityData)
+		if err != nil {
+			return nil, err
+		}
+
+		creds.Host = el.Cluster.Server
+		creds.ClusterCACertificate = ca
+	}
+
+	for _, el := range f.Users {
+		if el.Name != userName {
+			continue
+		}
+
+		cert, err := decode("client-certificate-data", el.User.ClientCertificateData)
+		if err != nil {
+			return nil, err
+		}
+
+		key, err := decode("client-key-data", el.User.ClientKeyData)
+		if err != nil {
+			return nil, err
+		}
+
+		creds.ClientCertificate = cert
+		creds.ClientKey = key
+		creds.Token = el.User.Token
+	}
+
+	if creds.Host == "" {
+		return nil, fmt.Errorf("kubeconfig has no server for context %q", name)
+	}
+
+	return &creds, nil
+}
+
+func decode(field, value string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return "", fmt.Errorf("invalid %s in kubeconfig: %w", field, err)
+	}
+
+	return string(data), nil
+}
@@ -0,0 +1,98 @@
+package kubeconfig
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	b64 := func(s string) string { return base64.StdEncoding.EncodeToString([]byte(s)) }
+
+	data := `
+apiVersion: v1
+kind: Config
+clusters:
+- name: other
+  cluster:
+    server: https://192.0.2.20:6443
+- name: prod
+  cluster:
+    server: https://192.0.2.10:6443
+    certificate-authority-data: ` + b64("ca") + `
+contexts:
+- name: other
+  context:
+    cluster: other
+    user: other
+- name: prod
+  context:
+    cluster: prod
+    user: prod-admin
+users:
+- name: prod-admin
+  user:
+    client-certificate-data: ` + b64("cert") + `
+    client-key-data: ` + b64("key") + `
+current-context: prod
+`
+
+	creds, err := Parse([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := Credentials{
+		Host:                 "https://192.0.2.10:6443",
+		ClusterCACertificate: "ca",
+		ClientCertificate:    "cert",
+		ClientKey:            "key",
+	}
+	if *creds != want {
+		t.Errorf("Parse() = %+v, want %+v", *creds, want)
+	}
+}
+
+func TestParse_errors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want error
+	}{
+		{
+			name: "empty",
+			data: "",
+			want: ErrNoContext,
+		},
+		{
+			name: "missing context",
+			data: "current-context: prod\n",
+		},
+		{
+			name: "invalid data",
+			data: `
+clusters:
+- name: prod
+  cluster:
+    server: https://192.0.2.10:6443
+    certificate-authority-data: "not base64"
+contexts:
+- name: prod
+  context:
+    cluster: prod
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.data))
+			if err == nil {
+				t.Fatal("Parse() succeeded")
+			}
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Errorf("Parse() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,305 @@
+// Package rest sends the calls of the CloudFerro Managed Kubernetes API as
+// HTTPS/JSON requests to its REST gateway, for networks where gRPC does not
+// get through. Conn implements grpc.ClientConnInterface, so the generated
+// clients, and everything built on them, work unchanged.
+package rest
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	grpcstatus "google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// maxResponseSize bounds the size of a response body.
+const maxResponseSize = 32 << 20
+
+// Conn sends unary calls to the REST gateway. It is safe for concurrent use.
+type Conn struct {
+	baseURL   *url.URL
+	host      string
+	userAgent string
+	http      *http.Client
+	creds     credentials.PerRPCCredentials
+	// invalidate, when set, drops the cached token after the gateway
+	// rejected it, the call is then retried once.
+	invalidate   func()
+	interceptors []grpc.UnaryClientInterceptor
+}
+
+var _ grpc.ClientConnInterface = (*Conn)(nil)
+
+// Options configure a Conn.
+type Options struct {
+	// Host overrides the Host header, such as when the TLS server name is
+	// overridden.
+	Host string
+	// UserAgent is sent as the User-Agent header.
+	UserAgent string
+	// Credentials are attached to every request as headers.
+	Credentials credentials.PerRPCCredentials
+	// Invalidate is called when the gateway rejects the credentials.
+	Invalidate func()
+	// Interceptors run around every call, like the ones of a gRPC
+	// connection. They are passed a nil *grpc.ClientConn.
+	Interceptors []grpc.UnaryClientInterceptor
+}
+
+// New returns a connection to the gateway at baseURL, sending requests with
+// httpClient.
+func New(baseURL string, httpClient *http.Client, opts Options) (*Conn, error) {
+	if len(routes) == 0 {
+		return nil, fmt.Errorf("no method of the API can be sent through the gateway: %w", routesErr)
+	}
+
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid gateway URL: %w", err)
+	}
+
+	return &Conn{
+		baseURL:      u,
+		host:         opts.Host,
+		userAgent:    opts.UserAgent,
+		http:         httpClient,
+		creds:        opts.Credentials,
+		invalidate:   opts.Invalidate,
+		interceptors: opts.Interceptors,
+	}, nil
+}
+
+// Close releases the idle connections.
+func (c *Conn) Close() error {
+	c.http.CloseIdleConnections()
+	return nil
+}
+
+// Invoke implements grpc.ClientConnInterface.
+func (c *Conn) Invoke(ctx context.Context, method string, args, reply any, opts ...grpc.CallOption) error {
+	return c.chain(0)(ctx, method, args, reply, nil, opts...)
+}
+
+// chain returns the invoker running the interceptors from i on.
+func (c *Conn) chain(i int) grpc.UnaryInvoker {
+	if i == len(c.interceptors) {
+		return c.send
+	}
+
+	return func(ctx context.Context, method string, args, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		return c.interceptors[i](ctx, method, args, reply, cc, c.chain(i+1), opts...)
+	}
+}
+
+// send sends a call, once more with a new token if the gateway rejected the
+// current one.
+func (c *Conn) send(
+	ctx context.Context, method string, args, reply any, _ *grpc.ClientConn, opts ...grpc.CallOption,
+) error {
+	err := c.invoke(ctx, method, args, reply, opts)
+	if grpcstatus.Code(err) == codes.Unauthenticated && c.invalidate != nil {
+		c.invalidate()
+		err = c.invoke(ctx, method, args, reply, opts)
+	}
+
+	return err
+}
+
+// NewStream implements grpc.ClientConnInterface. The gateway does not serve
+// streams, and the API has none.
+func (c *Conn) NewStream(context.Context, *grpc.StreamDesc, string, ...grpc.CallOption) (grpc.ClientStream, error) {
+	return nil, grpcstatus.Error(codes.Unimplemented, "streaming calls are not supported over the http transport")
+}
+
+func (c *Conn) invoke(ctx context.Context, method string, args, reply any, opts []grpc.CallOption) error {
+	r, ok := routes[strings.TrimPrefix(method, "/")]
+	if !ok {
+		return grpcstatus.Errorf(codes.Unimplemented, "%s has no REST mapping", method)
+	}
+
+	in, ok := args.(proto.Message)
+	if !ok {
+		return grpcstatus.Errorf(codes.Internal, "%s: request is not a protobuf message", method)
+	}
+
+	out, ok := reply.(proto.Message)
+	if !ok {
+		return grpcstatus.Errorf(codes.Internal, "%s: reply is not a protobuf message", method)
+	}
+
+	req, err := c.newRequest(ctx, r, in)
+	if err != nil {
+		// errors of the credentials already carry a code
+		if _, ok := grpcstatus.FromError(err); ok {
+			return err
+		}
+		return grpcstatus.Errorf(codes.Internal, "%s: %v", method, err)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return grpcstatus.FromContextError(ctx.Err()).Err()
+		}
+		return grpcstatus.Error(codes.Unavailable, err.Error())
+	}
+	defer resp.Body.Close()
+
+	setResponseMetadata(resp, opts)
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return grpcstatus.Errorf(codes.Unavailable, "failed to read response: %v", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return responseError(resp, data)
+	}
+
+	if err := (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(data, out); err != nil {
+		return grpcstatus.Errorf(codes.Internal, "failed to decode response: %v", err)
+	}
+
+	return nil
+}
+
+func (c *Conn) newRequest(ctx context.Context, r route, in proto.Message) (*http.Request, error) {
+	path, body, query, err := r.expand(in)
+	if err != nil {
+		return nil, err
+	}
+
+	u := c.baseURL.JoinPath(path)
+	u.RawQuery = query.Encode()
+
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, r.verb, u.String(), reader)
+	if err != nil {
+		return nil, err
+	}
+
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+
+	if c.host != "" {
+		req.Host = c.host
+	}
+
+	if c.userAgent != "" {
+		req.Header.Set("User-Agent", c.userAgent)
+	}
+
+	if c.creds != nil {
+		md, err := c.creds.GetRequestMetadata(ctx, u.String())
+		if err != nil {
+			return nil, err
+		}
+
+		for k, v := range md {
+			req.Header.Set(k, v)
+		}
+	}
+
+	// outgoing metadata, such as set by interceptors of the gRPC transport,
+	// is sent the way the gateway forwards it
+	if md, ok := metadata.FromOutgoingContext(ctx); ok {
+		for k, values := range md {
+			for _, v := range values {
+				req.Header.Add(headerMetadataPrefix+k, v)
+			}
+		}
+	}
+
+	return req, nil
+}
+
+// Prefixes of the headers the gateway returns the metadata of the service in.
+const (
+	headerMetadataPrefix  = "Grpc-Metadata-"
+	trailerMetadataPrefix = "Grpc-Trailer-"
+)
+
+// setResponseMetadata fills the metadata requested with the grpc.Header and
+// grpc.Trailer call options from the headers of resp.
+func setResponseMetadata(resp *http.Response, opts []grpc.CallOption) {
+	header, trailer := metadata.MD{}, metadata.MD{}
+	// the other headers are of the gateway, not metadata of the service
+	for k, values := range resp.Header {
+		if name, ok := strings.CutPrefix(k, trailerMetadataPrefix); ok {
+			trailer.Append(name, values...)
+		} else if name, ok := strings.CutPrefix(k, headerMetadataPrefix); ok {
+			header.Append(name, values...)
+		}
+	}
+
+	for _, el := range opts {
+		switch o := el.(type) {
+		case grpc.HeaderCallOption:
+			*o.HeaderAddr = header
+		case grpc.TrailerCallOption:
+			*o.TrailerAddr = trailer
+		}
+	}
+}
+
+// responseError converts an error response of the gateway, a google.rpc.Status
+// in JSON, into a status error, so it is handled like the gRPC one.
+func responseError(resp *http.Response, data []byte) error {
+	var st status.Status
+	if err := (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(data, &st); err == nil && st.GetCode() != 0 {
+		return grpcstatus.ErrorProto(&st)
+	}
+
+	msg := strings.TrimSpace(string(data))
+	if msg == "" {
+		msg = resp.Status
+	}
+
+	return grpcstatus.Error(httpStatusCode(resp.StatusCode), msg)
+}
+
+// httpStatusCode maps HTTP statuses to codes, the reverse of the gateway
+// mapping.
+func httpStatusCode(code int) codes.Code {
+	switch code {
+	case http.StatusBadRequest:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusConflict:
+		return codes.Aborted
+	case http.StatusPreconditionFailed:
+		return codes.FailedPrecondition
+	case http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case http.StatusNotImplemented:
+		return codes.Unimplemented
+	case http.StatusServiceUnavailable, http.StatusBadGateway:
+		return codes.Unavailable
+	case http.StatusGatewayTimeout:
+		return codes.DeadlineExceeded
+	default:
+		return codes.Unknown
+	}
+}
@@ -0,0 +1,231 @@
+package rest
+
+import (
+	"maps"
+	"net/http"
+	"strings"
+	"testing"
+
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	grpcstatus "google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+func TestRouteExpand(t *testing.T) {
+	tests := []struct {
+		name      string
+		route     route
+		request   string
+		wantPath  string
+		wantBody  string
+		wantQuery string
+	}{
+		{
+			name:      "query parameters",
+			route:     route{http.MethodGet, "/v1/clusters/{cluster_id}", ""},
+			request:   `{"cluster_id":"c1","extra_fields":"errors"}`,
+			wantPath:  "/v1/clusters/c1",
+			wantQuery: "extra_fields=errors",
+		},
+		{
+			name:     "body field",
+			route:    route{http.MethodPatch, "/v1/clusters/{cluster_id}/node-pools/{node_pool_id}", "node_pool"},
+			request:  `{"cluster_id":"c1","node_pool_id":"np1","node_pool":{"size":3}}`,
+			wantPath: "/v1/clusters/c1/node-pools/np1",
+			wantBody: `{"size":3}`,
+		},
+		{
+			name:      "nested and repeated query parameters",
+			route:     route{http.MethodGet, "/v1/clusters/{cluster_id}/node-pools", ""},
+			request:   `{"cluster_id":"c1","filter":{"status":"Running","size_min":2},"labels":["a","b"]}`,
+			wantPath:  "/v1/clusters/c1/node-pools",
+			wantQuery: "filter.size_min=2&filter.status=Running&labels=a&labels=b",
+		},
+		{
+			name:      "exact numbers",
+			route:     route{http.MethodGet, "/v1/clusters", ""},
+			request:   `{"page_size":1000000,"offset":9007199254740993,"ratio":0.5,"all":true}`,
+			wantPath:  "/v1/clusters",
+			wantQuery: "all=true&offset=9007199254740993&page_size=1000000&ratio=0.5",
+		},
+		{
+			name:     "exact numbers in the body",
+			route:    route{http.MethodPost, "/v1/clusters", "*"},
+			request:  `{"size":9007199254740993}`,
+			wantPath: "/v1/clusters",
+			wantBody: `{"size":9007199254740993}`,
+		},
+		{
+			name:     "path escaping",
+			route:    route{http.MethodDelete, "/v1/clusters/{cluster_id=*}", ""},
+			request:  `{"cluster_id":"a/b"}`,
+			wantPath: "/v1/clusters/a%2Fb",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, body, query, err := tt.route.expandFields([]byte(tt.request))
+			if err != nil {
+				t.Fatalf("expandFields() error = %v", err)
+			}
+
+			if path != tt.wantPath {
+				t.Errorf("path = %q, want %q", path, tt.wantPath)
+			}
+
+			if string(body) != tt.wantBody {
+				t.Errorf("body = %s, want %s", body, tt.wantBody)
+			}
+
+			if query.Encode() != tt.wantQuery {
+				t.Errorf("query = %q, want %q", query.Encode(), tt.wantQuery)
+			}
+		})
+	}
+}
+
+func TestRouteExpand_missingPathField(t *testing.T) {
+	r := route{http.MethodGet, "/v1/clusters/{cluster_id}/node-pools/{node_pool_id}", ""}
+	_, _, _, err := r.expandFields([]byte(`{"cluster_id":"c1"}`))
+	if err == nil {
+		t.Error("expandFields() succeeded without node_pool_id")
+	}
+}
+
+func TestResponseError(t *testing.T) {
+	resp := &http.Response{StatusCode: http.StatusConflict, Status: "409 Conflict"}
+
+	err := responseError(resp, []byte(`{"code":9,"message":"cluster is not running","details":[]}`))
+	if st := grpcstatus.Convert(err); st.Code() != codes.FailedPrecondition || st.Message() != "cluster is not running" {
+		t.Errorf("responseError() = %v, want the gateway status", err)
+	}
+
+	// not a gateway response, such as from a proxy
+	err = responseError(resp, []byte("conflict"))
+	if st := grpcstatus.Convert(err); st.Code() != codes.Aborted || st.Message() != "conflict" {
+		t.Errorf("responseError() = %v, want Aborted from the HTTP status", err)
+	}
+}
+
+func TestSetResponseMetadata(t *testing.T) {
+	resp := &http.Response{Header: http.Header{}}
+	resp.Header.Set("Grpc-Metadata-X-Request-Id", "req-1")
+	resp.Header.Set("Grpc-Trailer-X-Trace-Id", "trace-1")
+	resp.Header.Set("Set-Cookie", "session=1")
+
+	var header, trailer metadata.MD
+	setResponseMetadata(resp, []grpc.CallOption{grpc.Header(&header), grpc.Trailer(&trailer)})
+
+	if got := header.Get("x-request-id"); len(got) != 1 || got[0] != "req-1" {
+		t.Errorf("header x-request-id = %v, want req-1", got)
+	}
+
+	if got := header.Get("set-cookie"); len(got) != 0 {
+		t.Errorf("header set-cookie = %v, want the gateway headers left out", got)
+	}
+
+	if got := trailer.Get("x-trace-id"); len(got) != 1 || got[0] != "trace-1" {
+		t.Errorf("trailer x-trace-id = %v, want trace-1", got)
+	}
+}
+
+func TestLoadRoutes(t *testing.T) {
+	httpOption := func(rule *annotations.HttpRule) *descriptorpb.MethodOptions {
+		opts := &descriptorpb.MethodOptions{}
+		proto.SetExtension(opts, annotations.E_Http, rule)
+		return opts
+	}
+
+	// a service of the API, described in a file of the test
+	fullName := protoreflect.FullName(services[0].ServiceName)
+	message := proto.String("." + string(fullName.Parent()) + ".Message")
+	file, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:    proto.String("test.proto"),
+		Package: proto.String(string(fullName.Parent())),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{
+			{Name: proto.String("Message")},
+		},
+		Service: []*descriptorpb.ServiceDescriptorProto{{
+			Name: proto.String(string(fullName.Name())),
+			Method: []*descriptorpb.MethodDescriptorProto{
+				{
+					Name:       proto.String("Get"),
+					InputType:  message,
+					OutputType: message,
+					Options: httpOption(&annotations.HttpRule{
+						Pattern: &annotations.HttpRule_Get{Get: "/v1/things/{id}"},
+					}),
+				},
+				{
+					Name:       proto.String("Update"),
+					InputType:  message,
+					OutputType: message,
+					Options: httpOption(&annotations.HttpRule{
+						Pattern: &annotations.HttpRule_Patch{Patch: "/v1/things/{id}"},
+						Body:    "thing",
+					}),
+				},
+				{
+					Name:       proto.String("Unmapped"),
+					InputType:  message,
+					OutputType: message,
+				},
+			},
+		}},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files := &protoregistry.Files{}
+	if err := files.RegisterFile(file); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := loadRoutes(files)
+	want := map[string]route{
+		method(services[0].ServiceName, "Get"):    {http.MethodGet, "/v1/things/{id}", ""},
+		method(services[0].ServiceName, "Update"): {http.MethodPatch, "/v1/things/{id}", "thing"},
+	}
+	if !maps.Equal(got, want) {
+		t.Errorf("loadRoutes() = %v, want %v", got, want)
+	}
+
+	// the method without option and the services missing from files
+	for _, want := range []string{string(fullName) + ".Unmapped", services[1].ServiceName} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("loadRoutes() error = %v, want it to name %s", err, want)
+		}
+	}
+}
+
+func TestNew_noRoutes(t *testing.T) {
+	loaded := routes
+	routes = map[string]route{}
+	t.Cleanup(func() { routes = loaded })
+
+	if _, err := New("https://gateway.example", http.DefaultClient, Options{}); err == nil {
+		t.Error("New() succeeded without routes")
+	}
+}
+
+// TestRoutes checks that every method of the API can be sent through the
+// gateway.
+func TestRoutes(t *testing.T) {
+	for _, service := range services {
+		for _, el := range service.Methods {
+			if _, ok := routes[method(service.ServiceName, el.MethodName)]; !ok {
+				t.Errorf("%s/%s has no google.api.http option", service.ServiceName, el.MethodName)
+			}
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package rest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	clusterservice "gitlab.cloudferro.com/k8s/api/clusterservice/v1"
+	kubernetesversionservice "gitlab.cloudferro.com/k8s/api/kubernetesversionservice/v1"
+	machinespecservice "gitlab.cloudferro.com/k8s/api/machinespecservice/v1"
+	nodepoolservice "gitlab.cloudferro.com/k8s/api/nodepoolservice/v1"
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// route is the HTTP mapping of a method, as declared by its google.api.http
+// option.
+type route struct {
+	verb string
+	// path is a template whose {field} segments are taken from the request.
+	path string
+	// body is the request field sent as the body, "*" for all the fields
+	// not in the path. Without a body the remaining fields are sent as
+	// query parameters.
+	body string
+}
+
+func method(service, name string) string {
+	return service + "/" + name
+}
+
+// services are the services of the API sent through the gateway.
+var services = []*grpc.ServiceDesc{
+	&clusterservice.Cluster_ServiceDesc,
+	&nodepoolservice.NodePool_ServiceDesc,
+	&kubernetesversionservice.KubernetesVersion_ServiceDesc,
+	&machinespecservice.MachineSpec_ServiceDesc,
+}
+
+// routes maps the full method names, without the leading slash, to their
+// REST mapping, read from the google.api.http options of the methods.
+// routesErr tells which services and methods have none.
+var routes, routesErr = loadRoutes(protoregistry.GlobalFiles)
+
+// loadRoutes returns the routes of the services described in files. The
+// error lists the services and methods without a route, the others are
+// returned regardless.
+func loadRoutes(files *protoregistry.Files) (map[string]route, error) {
+	routes := map[string]route{}
+	var errs []error
+
+	for _, el := range services {
+		desc, err := files.FindDescriptorByName(protoreflect.FullName(el.ServiceName))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("service %s: %w", el.ServiceName, err))
+			continue
+		}
+
+		service, ok := desc.(protoreflect.ServiceDescriptor)
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s is not a service", el.ServiceName))
+			continue
+		}
+
+		methods := service.Methods()
+		for i := range methods.Len() {
+			m := methods.Get(i)
+			rule, _ := proto.GetExtension(m.Options(), annotations.E_Http).(*annotations.HttpRule)
+			if r, ok := ruleRoute(rule); ok {
+				routes[method(el.ServiceName, string(m.Name()))] = r
+			} else {
+				errs = append(errs, fmt.Errorf("method %s has no google.api.http option", m.FullName()))
+			}
+		}
+	}
+
+	return routes, errors.Join(errs...)
+}
+
+// ruleRoute returns the route of a google.api.http option, if it has one.
+func ruleRoute(rule *annotations.HttpRule) (route, bool) {
+	r := route{body: rule.GetBody()}
+
+	switch p := rule.GetPattern().(type) {
+	case *annotations.HttpRule_Get:
+		r.verb, r.path = http.MethodGet, p.Get
+	case *annotations.HttpRule_Put:
+		r.verb, r.path = http.MethodPut, p.Put
+	case *annotations.HttpRule_Post:
+		r.verb, r.path = http.MethodPost, p.Post
+	case *annotations.HttpRule_Delete:
+		r.verb, r.path = http.MethodDelete, p.Delete
+	case *annotations.HttpRule_Patch:
+		r.verb, r.path = http.MethodPatch, p.Patch
+	case *annotations.HttpRule_Custom:
+		r.verb, r.path = p.Custom.GetKind(), p.Custom.GetPath()
+	default:
+		return route{}, false
+	}
+
+	return r, true
+}
+
+// expand returns the path, body and query parameters of the request in.
+func (r route) expand(in proto.Message) (string, []byte, url.Values, error) {
+	data, err := protojson.MarshalOptions{UseProtoNames: true}.Marshal(in)
+	if err != nil {
+		return "", nil, nil, err
+	}
+
+	return r.expandFields(data)
+}
+
+// expandFields does the work of expand on the JSON encoding of the request.
+func (r route) expandFields(data []byte) (string, []byte, url.Values, error) {
+	// numbers are kept as written, 64-bit integers lose precision as floats
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var fields map[string]any
+	if err := dec.Decode(&fields); err != nil {
+		return "", nil, nil, err
+	}
+
+	segments := strings.Split(r.path, "/")
+	for i, el := range segments {
+		if !strings.HasPrefix(el, "{") {
+			continue
+		}
+
+		// the pattern of the segment, as in {cluster_id=*}, is not checked
+		name, _, _ := strings.Cut(strings.Trim(el, "{}"), "=")
+		value, _ := fields[name].(string)
+		if value == "" {
+			return "", nil, nil, fmt.Errorf("missing %s", name)
+		}
+
+		segments[i] = url.PathEscape(value)
+		delete(fields, name)
+	}
+	path := strings.Join(segments, "/")
+
+	switch r.body {
+	case "":
+		query := url.Values{}
+		for name, value := range fields {
+			addQuery(query, name, value)
+		}
+		return path, nil, query, nil
+	case "*":
+		body, err := json.Marshal(fields)
+		return path, body, nil, err
+	default:
+		value, ok := fields[r.body]
+		if !ok {
+			value = map[string]any{}
+		}
+		body, err := json.Marshal(value)
+		return path, body, nil, err
+	}
+}
+
+// addQuery adds the query parameters of a request field. The fields of
+// messages are named by their dotted path, as in "node_pool.size", and
+// repeated fields are repeated parameters.
+func addQuery(query url.Values, name string, value any) {
+	switch v := value.(type) {
+	case map[string]any:
+		for field, el := range v {
+			addQuery(query, name+"."+field, el)
+		}
+	case []any:
+		for _, el := range v {
+			addQuery(query, name, el)
+		}
+	case string:
+		query.Add(name, v)
+	case json.Number:
+		query.Add(name, v.String())
+	case bool:
+		query.Add(name, strconv.FormatBool(v))
+	}
+}
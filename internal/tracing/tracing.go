@@ -0,0 +1,116 @@
+// Package tracing exports OpenTelemetry traces of the provider, configured
+// with the standard OTEL_EXPORTER_OTLP_* environment variables.
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// Environment variables read by Setup. The exporters read the other
+// OTEL_EXPORTER_OTLP_* variables, such as the headers and certificates.
+const (
+	EnvEndpoint       = "OTEL_EXPORTER_OTLP_ENDPOINT"
+	EnvTracesEndpoint = "OTEL_EXPORTER_OTLP_TRACES_ENDPOINT"
+	EnvProtocol       = "OTEL_EXPORTER_OTLP_PROTOCOL"
+	EnvTracesProtocol = "OTEL_EXPORTER_OTLP_TRACES_PROTOCOL"
+)
+
+// fileScheme prefixes endpoints which are files the spans are appended to,
+// one JSON object per line, rather than collectors.
+const fileScheme = "file://"
+
+// Setup installs the global tracer provider when an OTLP endpoint is set and
+// returns the function flushing the spans and shutting it down. Without an
+// endpoint nothing is exported and the spans are not recorded.
+func Setup(ctx context.Context, serviceName, serviceVersion string) (func(context.Context) error, error) {
+	endpoint := env(EnvTracesEndpoint, EnvEndpoint)
+	if endpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporter, closeExporter, err := newExporter(ctx, endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create trace exporter: %w", err)
+	}
+
+	// OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES take precedence
+	res, err := resource.New(ctx,
+		resource.WithAttributes(
+			attribute.String("service.name", serviceName),
+			attribute.String("service.version", serviceVersion),
+		),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+	)
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("failed to describe trace resource: %w", err), closeExporter())
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{}, propagation.Baggage{},
+	))
+
+	return func(ctx context.Context) error {
+		return errors.Join(provider.Shutdown(ctx), closeExporter())
+	}, nil
+}
+
+// newExporter returns the exporter of the endpoint and the function releasing
+// what it holds once it is shut down.
+func newExporter(ctx context.Context, endpoint string) (sdktrace.SpanExporter, func() error, error) {
+	noop := func() error { return nil }
+
+	if name, ok := strings.CutPrefix(endpoint, fileScheme); ok {
+		f, err := os.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(f))
+		if err != nil {
+			return nil, nil, errors.Join(err, f.Close())
+		}
+
+		return exporter, f.Close, nil
+	}
+
+	switch protocol := env(EnvTracesProtocol, EnvProtocol); protocol {
+	case "grpc":
+		exporter, err := otlptracegrpc.New(ctx)
+		return exporter, noop, err
+	case "", "http/protobuf":
+		exporter, err := otlptracehttp.New(ctx)
+		return exporter, noop, err
+	default:
+		return nil, nil, fmt.Errorf("unsupported OTLP protocol %q, must be grpc or http/protobuf", protocol)
+	}
+}
+
+// env returns the first of the variables which is set.
+func env(names ...string) string {
+	for _, el := range names {
+		if v := os.Getenv(el); v != "" {
+			return v
+		}
+	}
+
+	return ""
+}
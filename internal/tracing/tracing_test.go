@@ -0,0 +1,48 @@
+package tracing
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+)
+
+func TestSetup_file(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "traces.jsonl")
+	t.Setenv(EnvEndpoint, "")
+	t.Setenv(EnvTracesEndpoint, fileScheme+name)
+
+	shutdown, err := Setup(context.Background(), "test", "dev")
+	if err != nil {
+		t.Fatalf("Setup() error = %v", err)
+	}
+
+	_, span := otel.Tracer("test").Start(context.Background(), "cloudferro_kubernetes_cluster_v1.Create")
+	span.End()
+
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown() error = %v", err)
+	}
+
+	data, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(string(data), `"Name":"cloudferro_kubernetes_cluster_v1.Create"`) {
+		t.Errorf("traces file does not contain the span: %s", data)
+	}
+}
+
+func TestSetup_unsupportedProtocol(t *testing.T) {
+	t.Setenv(EnvEndpoint, "http://localhost:4318")
+	t.Setenv(EnvTracesEndpoint, "")
+	t.Setenv(EnvTracesProtocol, "http/json")
+
+	if _, err := Setup(context.Background(), "test", "dev"); err == nil {
+		t.Error("Setup() succeeded with an unsupported protocol")
+	}
+}
@@ -6,7 +6,10 @@ import (
 	"log"
 
 	"github.com/cloudferro/terraform-provider-cloudferro/cloudferro"
+	"github.com/cloudferro/terraform-provider-cloudferro/internal/tracing"
 	"github.com/hashicorp/terraform-plugin-framework/providerserver"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace/noop"
 )
 
 // Run "go generate" to format example terraform files and generate the docs for the registry/website
@@ -27,14 +30,31 @@ func main() {
 	flag.BoolVar(&debug, "debug", false, "set to true to run the provider with debug logs")
 	flag.Parse()
 
-	err := providerserver.Serve(
-		context.Background(),
+	ctx := context.Background()
+
+	shutdown, err := tracing.Setup(ctx, "terraform-provider-cloudferro", version)
+	if err != nil {
+		// traces are optional, they must not keep the provider from serving
+		// Terraform and showing its diagnostics
+		log.Printf("[WARN] tracing disabled: %v", err)
+		otel.SetTracerProvider(noop.NewTracerProvider())
+		shutdown = func(context.Context) error { return nil }
+	}
+
+	err = providerserver.Serve(
+		ctx,
 		cloudferro.NewProvider(version),
 		providerserver.ServeOpts{
 			Address: "registry.terraform.io/cloudferro/cloudferro",
 			Debug:   debug,
 		},
 	)
+
+	// the spans still buffered are exported before exiting
+	if shutdownErr := shutdown(ctx); shutdownErr != nil {
+		log.Printf("failed to export traces: %v", shutdownErr)
+	}
+
 	if err != nil {
 		log.Fatal(err)
 	}